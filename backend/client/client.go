@@ -0,0 +1,232 @@
+// Package client Beast Royale /api 的Go客户端
+//
+// 每个Action对应的类型和方法由 `go generate ./client` 根据api包中注册的Schema生成（actions_gen.go），
+// 本文件提供会话（cookie jar）、重试和错误处理等通用逻辑
+package client
+
+//go:generate go run .. gen-client --output actions_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BaseResponse 所有Action响应的公共字段
+type BaseResponse struct {
+	Action      string `json:"Action,omitempty"`
+	RequestUUID string `json:"RequestUUID"`
+	RetCode     int    `json:"RetCode"`
+	Message     string `json:"Message,omitempty"`
+}
+
+// Response Action响应
+type Response interface {
+	base() *BaseResponse
+}
+
+func (br *BaseResponse) base() *BaseResponse {
+	return br
+}
+
+// Client /api客户端，登录后的session保存在cookie jar中，可并发使用
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	adminKey   string
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的http.Client，未设置Jar时会自动创建
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetry 设置最大重试次数和首次重试的等待时间（之后按指数增长）
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithAdminKey 调用管理Action（例如GetAuditTrail）时使用的密钥，通过 X-Admin-Key 请求头发送
+func WithAdminKey(key string) Option {
+	return func(c *Client) {
+		c.adminKey = key
+	}
+}
+
+// New 创建客户端，baseURL例如 http://localhost:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		c.httpClient.Jar = jar
+	}
+	return c, nil
+}
+
+// Call 调用Action，req会按JSON字段名展开为Action参数
+//
+// 重试策略：
+//   - 被限流（429）的请求没有被执行，总是按Retry-After重试
+//   - idempotent为true时，网络错误和5xx也会重试
+//
+// 同一次调用的所有重试使用相同的RequestUUID，便于服务端日志关联。
+// RetCode不为0（206部分成功除外）时返回*Error
+func (c *Client) Call(ctx context.Context, action string, req interface{}, resp Response, idempotent bool) error {
+	params := make(map[string]interface{})
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return fmt.Errorf("request must be an object: %w", err)
+		}
+	}
+	params["Action"] = action
+	if reqUUID, _ := params["RequestUUID"].(string); reqUUID == "" {
+		params["RequestUUID"] = uuid.NewString()
+	}
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err = c.do(ctx, body, resp)
+		if err == nil || attempt >= c.maxRetries || !c.retryable(err, idempotent) {
+			return err
+		}
+
+		wait := backoff
+		if e, ok := err.(*Error); ok && e.RetryAfter > 0 {
+			wait = e.RetryAfter
+		}
+		backoff *= 2
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, body []byte, resp Response) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if c.adminKey != "" {
+		httpReq.Header.Set("X-Admin-Key", c.adminKey)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return &transportError{err: err}
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return &transportError{err: err}
+	}
+
+	base := resp.base()
+	if err := json.Unmarshal(data, resp); err != nil {
+		// 网关等返回的非JSON错误
+		if httpResp.StatusCode >= 400 {
+			return newError(httpResp, base, strings.TrimSpace(string(data)))
+		}
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	if base.RetCode == 0 && httpResp.StatusCode >= 400 {
+		// PreJob等中间件的错误响应没有RetCode，使用HTTP状态码
+		var errBody struct {
+			Message string `json:"Message"`
+			Error   string `json:"Error"`
+		}
+		_ = json.Unmarshal(data, &errBody)
+		return newError(httpResp, base, strings.TrimSpace(errBody.Message+" "+errBody.Error))
+	}
+	if base.RetCode != 0 && base.RetCode != PARTIAL_SUCCESS_CODE {
+		return newError(httpResp, base, base.Message)
+	}
+	return nil
+}
+
+func (c *Client) retryable(err error, idempotent bool) bool {
+	if e, ok := err.(*Error); ok {
+		if e.RetCode == RATE_LIMITED_CODE {
+			return true
+		}
+		return idempotent && (e.RetCode >= 500 || e.StatusCode >= 500)
+	}
+	_, ok := err.(*transportError)
+	return ok && idempotent
+}
+
+func newError(httpResp *http.Response, base *BaseResponse, message string) *Error {
+	e := &Error{
+		Action:      base.Action,
+		RequestUUID: base.RequestUUID,
+		RetCode:     base.RetCode,
+		Message:     message,
+		StatusCode:  httpResp.StatusCode,
+	}
+	if e.RetCode == 0 {
+		e.RetCode = httpResp.StatusCode
+	}
+	if seconds, err := strconv.Atoi(httpResp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+// transportError 请求没有得到服务端响应
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 与服务端一致的RetCode
+const (
+	PARTIAL_SUCCESS_CODE = 206 // 部分成功，不作为错误返回
+	BAD_REQUEST_CODE     = 400
+	UNAUTHORIZED_CODE    = 401
+	FORBIDDEN_CODE       = 403
+	NOT_FOUND_CODE       = 404
+	RATE_LIMITED_CODE    = 429
+	INTERNAL_ERROR_CODE  = 500
+)
+
+// Error 服务端返回的错误，RetCode为Action响应中的RetCode，中间件返回的错误使用HTTP状态码
+type Error struct {
+	Action      string
+	RequestUUID string
+	RetCode     int
+	Message     string
+	StatusCode  int           // HTTP状态码
+	RetryAfter  time.Duration // 被限流时建议的等待时间
+}
+
+func (e *Error) Error() string {
+	if e.Action != "" {
+		return fmt.Sprintf("%s failed: RetCode=%d, Message=%s, RequestUUID=%s", e.Action, e.RetCode, e.Message, e.RequestUUID)
+	}
+	return fmt.Sprintf("request failed: RetCode=%d, Message=%s", e.RetCode, e.Message)
+}
+
+// RetCodeOf 返回错误中的RetCode，不是*Error时返回0
+func RetCodeOf(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetCode
+	}
+	return 0
+}
+
+// IsUnauthorized session不存在或已过期，需要重新登录
+func IsUnauthorized(err error) bool {
+	return RetCodeOf(err) == UNAUTHORIZED_CODE
+}
+
+// IsRateLimited 请求被限流
+func IsRateLimited(err error) bool {
+	return RetCodeOf(err) == RATE_LIMITED_CODE
+}
+
+// IsBadRequest 请求参数错误
+func IsBadRequest(err error) bool {
+	return RetCodeOf(err) == BAD_REQUEST_CODE
+}
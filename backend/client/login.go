@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+
+	"beast-royale-backend/internal/wallet"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LoginWithPrivateKey 使用私钥完成钱包登录（ConnectWallet + VerifySignature），session保存在cookie jar中
+func (c *Client) LoginWithPrivateKey(ctx context.Context, key *ecdsa.PrivateKey) (*VerifySignatureResponse, error) {
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	connected, err := c.ConnectWallet(ctx, &ConnectWalletRequest{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := wallet.SignMessage(key, wallet.LoginMessage(connected.Nonce))
+	if err != nil {
+		return nil, fmt.Errorf("sign login message: %w", err)
+	}
+
+	return c.VerifySignature(ctx, &VerifySignatureRequest{
+		Address:   address,
+		Signature: signature,
+		Nonce:     connected.Nonce,
+	})
+}
+
+// LoginWithHexKey 使用十六进制私钥登录
+func (c *Client) LoginWithHexKey(ctx context.Context, hexKey string) (*VerifySignatureResponse, error) {
+	key, err := crypto.HexToECDSA(trimHexPrefix(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return c.LoginWithPrivateKey(ctx, key)
+}
+
+// LoginWithKeystore 使用go-ethereum keystore文件（JSON）登录
+func (c *Client) LoginWithKeystore(ctx context.Context, keystoreJSON []byte, passphrase string) (*VerifySignatureResponse, error) {
+	key, err := keystore.DecryptKey(keystoreJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %w", err)
+	}
+	return c.LoginWithPrivateKey(ctx, key.PrivateKey)
+}
+
+// LoginWithKeystoreFile 读取keystore文件并登录
+func (c *Client) LoginWithKeystoreFile(ctx context.Context, path, passphrase string) (*VerifySignatureResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.LoginWithKeystore(ctx, data, passphrase)
+}
+
+func trimHexPrefix(s string) string {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		return s[2:]
+	}
+	return s
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"beast-royale-backend/internal/admin"
+	"beast-royale-backend/internal/db"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	adminDryRun bool
+	adminActor  string
+	adminReason string
+	adminYes    bool
+)
+
+// adminCmd represents the admin command
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "operator commands for player accounts and admin keys",
+	Long: `Inspect and fix player accounts without writing SQL. Accounts are given by
+address (0x...) or username. Every change runs in a transaction together with
+an audit_event row recording the actor, reason and changed fields; with
+--dry-run the change is checked and printed but the transaction is rolled back,
+and a separate audit_event row with the action suffixed by " --dry-run" records
+the check.
+
+Changes made here are not pushed to connected clients, they see them on the
+next GetUserProfile.`,
+}
+
+var adminUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "show and change a player account",
+}
+
+var adminUserShowCmd = &cobra.Command{
+	Use:   "show <address|username>",
+	Short: "print an account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, err := mustAdminUsers().Find(context.Background(), args[0])
+		if err != nil {
+			fmt.Printf("show user failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		out, _ := json.MarshalIndent(profile, "", "  ")
+		fmt.Println(string(out))
+	},
+}
+
+var adminUserGrantTokensCmd = &cobra.Command{
+	Use:   "grant-tokens <address|username> <amount>",
+	Short: "add tokens to an account, a negative amount (after --) deducts",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount := mustParseInt(args[1])
+		result, err := mustAdminUsers().GrantTokens(context.Background(), args[0], amount)
+		printAdminResult("grant tokens", result, err)
+	},
+}
+
+var adminUserSetPointsCmd = &cobra.Command{
+	Use:   "set-points <address|username> <points>",
+	Short: "set the points of an account",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		points := mustParseInt(args[1])
+		result, err := mustAdminUsers().SetPoints(context.Background(), args[0], points)
+		printAdminResult("set points", result, err)
+	},
+}
+
+var adminUserRenameCmd = &cobra.Command{
+	Use:   "rename <address|username> <new-username>",
+	Short: "change the username, ignoring the 24 hour cooldown",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := mustAdminUsers().Rename(context.Background(), args[0], args[1])
+		printAdminResult("rename", result, err)
+	},
+}
+
+var adminUserSuspendCmd = &cobra.Command{
+	Use:   "suspend <address|username>",
+	Short: "block login and profile access for an account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := mustAdminUsers().Suspend(context.Background(), args[0])
+		printAdminResult("suspend", result, err)
+	},
+}
+
+var adminUserUnsuspendCmd = &cobra.Command{
+	Use:   "unsuspend <address|username>",
+	Short: "lift a suspension",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := mustAdminUsers().Unsuspend(context.Background(), args[0])
+		printAdminResult("unsuspend", result, err)
+	},
+}
+
+var adminUserDeleteCmd = &cobra.Command{
+	Use:   "delete <address|username>",
+	Short: "delete an account, a new one is created on the next login",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !adminYes && !adminDryRun {
+			fmt.Println("deleting an account cannot be undone, pass --yes to confirm or --dry-run to check")
+			os.Exit(-1)
+		}
+		result, err := mustAdminUsers().Delete(context.Background(), args[0])
+		printAdminResult("delete", result, err)
+	},
+}
+
+var adminKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "manage keys for admin API actions",
+}
+
+var adminKeyGenerateCmd = &cobra.Command{
+	Use:   "generate <name>",
+	Short: "create a key for admin actions such as GetAuditTrail",
+	Long: `Print a new random key and the line to add under security.admin_api_keys.
+Only the SHA-256 of the key is stored in the config, the key itself is shown
+once. Callers send it in the X-Admin-Key header and audit events record the
+name. Restart the service after changing the config.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, hash, err := admin.GenerateKey()
+		if err != nil {
+			fmt.Printf("generate key failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Printf("key: %s\n\nadd to the config:\n\nsecurity:\n  admin_api_keys:\n    %s: %q\n", key, args[0], hash)
+	},
+}
+
+// mustAdminUsers 加载配置并连接数据库
+func mustAdminUsers() *admin.Users {
+	mustInitDB()
+	return admin.NewUsers(db.NewStore(db.GetDB()), admin.Operator{
+		Actor:  adminActor,
+		Reason: adminReason,
+		DryRun: adminDryRun,
+	})
+}
+
+func mustParseInt(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		fmt.Printf("invalid number %q\n", s)
+		os.Exit(-1)
+	}
+	return n
+}
+
+func printAdminResult(verb string, result *admin.Result, err error) {
+	if err != nil {
+		fmt.Printf("%s failed: %+v\n", verb, err)
+		os.Exit(-1)
+	}
+	if result.Deleted {
+		fmt.Printf("deleted %s (%s)\n", result.Before.Address, result.Before.Username)
+	}
+	for _, change := range result.Changes {
+		fmt.Printf("%s: %q -> %q\n", change.Field, change.From, change.To)
+	}
+	if adminDryRun {
+		fmt.Println("dry run, nothing was saved")
+	}
+}
+
+// currentUser 默认的操作人，取当前系统用户
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func init() {
+	adminCmd.PersistentFlags().BoolVar(&adminDryRun, "dry-run", false, "check and print the change without saving it")
+	adminCmd.PersistentFlags().StringVar(&adminActor, "actor", currentUser(), "operator name recorded in the audit event")
+	adminCmd.PersistentFlags().StringVar(&adminReason, "reason", "", "why the change is made, recorded in the audit event")
+	adminUserDeleteCmd.Flags().BoolVar(&adminYes, "yes", false, "confirm deleting the account")
+
+	adminUserCmd.AddCommand(adminUserShowCmd, adminUserGrantTokensCmd, adminUserSetPointsCmd,
+		adminUserRenameCmd, adminUserSuspendCmd, adminUserUnsuspendCmd, adminUserDeleteCmd)
+	adminKeyCmd.AddCommand(adminKeyGenerateCmd)
+	adminCmd.AddCommand(adminUserCmd, adminKeyCmd)
+	rootCmd.AddCommand(adminCmd)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"beast-royale-backend/internal/config"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "inspect the service configuration",
+}
+
+// configCheckCmd represents the config check command
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "print the effective config with secrets masked and validate it",
+	Long: `Load the config the same way as the run command (defaults, config file,
+BEAST_* environment variables, then --set flags), print the result with secrets
+masked, and exit with a non-zero code if the config is invalid.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load(configPath, configOverrides...)
+		if err != nil {
+			fmt.Printf("load config failed: %+v\n", err)
+			os.Exit(-1)
+		}
+
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg.Masked()); err != nil {
+			fmt.Printf("marshal config failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		enc.Close()
+
+		if err := cfg.Validate(); err != nil {
+			fmt.Printf("\n%v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Println("\nconfig is valid")
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"beast-royale-backend/internal/config"
 	"beast-royale-backend/internal/db"
@@ -10,40 +13,155 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	migrateSteps       int
+	migrateLockTimeout time.Duration
+)
+
 // dbMigrateCmd represents the db-migrate command
 var dbMigrateCmd = &cobra.Command{
 	Use:   "db-migrate",
-	Short: "create tables in the target db",
+	Short: "apply or roll back versioned schema migrations",
+	Long: `Run the SQL migrations embedded in the binary against the configured database.
+Applied versions are recorded in the schema_migrations table, and a lock keeps
+two instances from migrating at the same time. The run command does not change
+the schema, so run db-migrate up before starting a new version.
+
+Running db-migrate without a subcommand is the same as db-migrate up.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// 加载配置文件
-		if configPath == "" {
-			configPath = "config.yaml"
-		}
-		err := config.InitConfig(configPath)
+		migrateUp()
+	},
+}
+
+var dbMigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "apply all pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrateUp()
+	},
+}
+
+var dbMigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "roll back the latest applied migrations (one by default)",
+	Run: func(cmd *cobra.Command, args []string) {
+		m := mustMigrator()
+		done, err := m.Down(context.Background(), migrateSteps)
+		printMigrations("rolled back", done)
 		if err != nil {
-			fmt.Printf("load config failed: %+v\n", err)
+			fmt.Printf("db migrate down failed: %+v\n", err)
 			os.Exit(-1)
 		}
+		if len(done) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	},
+}
 
-		err = db.Init()
+var dbMigrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "migrate up or down to the given version, 0 rolls back everything",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			fmt.Printf("invalid version %q\n", args[0])
+			os.Exit(-1)
+		}
+		m := mustMigrator()
+		done, up, err := m.To(context.Background(), version)
+		if up {
+			printMigrations("applied", done)
+		} else {
+			printMigrations("rolled back", done)
+		}
 		if err != nil {
-			fmt.Printf("init db failed: %+v\n", err)
+			fmt.Printf("db migrate to %d failed: %+v\n", version, err)
 			os.Exit(-1)
 		}
-		err = migrate()
+		if len(done) == 0 {
+			fmt.Printf("already at version %d\n", version)
+		}
+	},
+}
+
+var dbMigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "list migrations and whether they are applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		m := mustMigrator()
+		statuses, err := m.Status(context.Background())
 		if err != nil {
-			fmt.Printf("create db table failed: %+v\n", err)
+			fmt.Printf("db migrate status failed: %+v\n", err)
 			os.Exit(-1)
 		}
-		fmt.Println("db migrate success!")
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				state += " (file missing)"
+			}
+			fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, state)
+		}
 	},
 }
 
-func init() {
-	rootCmd.AddCommand(dbMigrateCmd)
-	dbMigrateCmd.Flags().StringVarP(&configPath, "config", "c", "", "config file path")
+func migrateUp() {
+	m := mustMigrator()
+	done, err := m.Up(context.Background())
+	printMigrations("applied", done)
+	if err != nil {
+		fmt.Printf("db migrate up failed: %+v\n", err)
+		os.Exit(-1)
+	}
+	if len(done) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	fmt.Println("db migrate success!")
+}
+
+// mustMigrator 加载配置并连接数据库
+func mustMigrator() *db.Migrator {
+	mustInitDB()
+	m, err := db.NewMigrator(db.GetDB(), config.Get().Database.Driver)
+	if err != nil {
+		fmt.Printf("load migrations failed: %+v\n", err)
+		os.Exit(-1)
+	}
+	m.LockTimeout = migrateLockTimeout
+	return m
 }
 
-func migrate() error {
-	return db.Migrate()
+// mustInitDB 加载配置并连接数据库，供不启动服务的命令使用
+func mustInitDB() {
+	if configPath == "" {
+		configPath = "config.yaml"
+	}
+	err := config.InitConfig(configPath, configOverrides...)
+	if err != nil {
+		fmt.Printf("load config failed: %+v\n", err)
+		os.Exit(-1)
+	}
+
+	err = db.Init()
+	if err != nil {
+		fmt.Printf("init db failed: %+v\n", err)
+		os.Exit(-1)
+	}
+}
+
+func printMigrations(verb string, list []db.Migration) {
+	for _, m := range list {
+		fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+	}
+}
+
+func init() {
+	dbMigrateCmd.PersistentFlags().DurationVar(&migrateLockTimeout, "lock-timeout", db.DEFAULT_MIGRATION_LOCK_TIMEOUT, "how long to wait for another instance to finish migrating")
+	dbMigrateDownCmd.Flags().IntVar(&migrateSteps, "steps", 1, "number of migrations to roll back")
+
+	dbMigrateCmd.AddCommand(dbMigrateUpCmd, dbMigrateDownCmd, dbMigrateToCmd, dbMigrateStatusCmd)
+	rootCmd.AddCommand(dbMigrateCmd)
 }
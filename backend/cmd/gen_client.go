@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"beast-royale-backend/internal/clientgen"
+
+	"github.com/spf13/cobra"
+)
+
+var genClientOutput string
+
+// genClientCmd represents the gen-client command
+var genClientCmd = &cobra.Command{
+	Use:   "gen-client",
+	Short: "generate the typed Go client from the action registry",
+	Run: func(cmd *cobra.Command, args []string) {
+		var buf bytes.Buffer
+		if err := clientgen.Generate(&buf); err != nil {
+			fmt.Printf("generate client failed: %+v\n", err)
+			os.Exit(-1)
+		}
+
+		if genClientOutput == "" || genClientOutput == "-" {
+			os.Stdout.Write(buf.Bytes())
+			return
+		}
+		if err := os.WriteFile(genClientOutput, buf.Bytes(), 0644); err != nil {
+			fmt.Printf("write client failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Printf("client generated: %s\n", genClientOutput)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(genClientCmd)
+	genClientCmd.Flags().StringVarP(&genClientOutput, "output", "o", "client/actions_gen.go", "output file, - for stdout")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/mock"
+	"beast-royale-backend/server/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockFixtures string
+	mockPort     int
+	mockScenario string
+	mockAddress  string
+)
+
+// mockCmd represents the mock command
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "serve /api from recorded fixtures for frontend development",
+	Long: `Serve /api from fixtures recorded by a server with recording.enabled.
+No MySQL, Redis or wallet is needed: VerifySignature accepts any signature and creates a mock session.
+Scenarios can be switched with --scenario or PUT /mock/scenario {"Scenario": "session-expired"}.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 配置文件可选，只用于读取fixture目录和cookie名称，不需要数据库等配置，因此不做校验
+		cookieName := ""
+		var loggingConfig config.LoggingConfig
+		corsConfig := mockCORSConfig
+		if configPath != "" {
+			cfg, err := config.Load(configPath, configOverrides...)
+			if err != nil {
+				fmt.Printf("load config failed: %+v\n", err)
+				os.Exit(-1)
+			}
+			if mockFixtures == "" {
+				mockFixtures = cfg.Recording.Dir
+			}
+			cookieName = cfg.Security.SessionName
+			loggingConfig = cfg.Logging
+			if len(cfg.CORS.AllowedOrigins) > 0 {
+				corsConfig = cfg.CORS
+			}
+		}
+		if mockFixtures == "" {
+			mockFixtures = "fixtures"
+		}
+
+		if err := logger.Init(loggingConfig); err != nil {
+			fmt.Printf("init logger failed: %+v\n", err)
+			os.Exit(-1)
+		}
+
+		// 确保API包被初始化，用于判断Action的鉴权类型
+		_ = api.GetAllAction()
+
+		store, err := mock.LoadStore(mockFixtures)
+		if err != nil {
+			fmt.Printf("load fixtures failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		scenarios, err := mock.LoadScenarios(mockFixtures)
+		if err != nil {
+			fmt.Printf("load scenarios failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		if _, ok := scenarios[mockScenario]; mockScenario != "" && !ok {
+			fmt.Printf("unknown scenario: %s\n", mockScenario)
+			os.Exit(-1)
+		}
+
+		r := gin.New()
+		r.Use(gin.Logger(), gin.Recovery(), middleware.CORSMiddleware(middleware.NewCORSPolicy(&corsConfig)))
+		mock.NewServer(store, scenarios, mock.Options{
+			CookieName: cookieName,
+			Scenario:   mockScenario,
+			Address:    mockAddress,
+		}).Register(r)
+
+		addr := fmt.Sprintf("0.0.0.0:%d", mockPort)
+		fmt.Printf("mock server running on: %s, fixtures: %d from %s\n", addr, store.Count(), mockFixtures)
+		if err := http.ListenAndServe(addr, r); err != nil {
+			fmt.Printf("mock server stopped: %+v\n", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// mockCORSConfig 没有配置文件或配置中没有跨域来源时，允许本地前端开发服务器访问
+var mockCORSConfig = config.CORSConfig{
+	AllowedOrigins: []string{
+		"http://localhost:5173",
+		"http://localhost:5174",
+		"http://localhost:3000",
+		"http://localhost:3001",
+	},
+	AllowCredentials: true,
+}
+
+func init() {
+	rootCmd.AddCommand(mockCmd)
+	mockCmd.Flags().StringVarP(&mockFixtures, "fixtures", "f", "", "fixture directory (default recording.dir or fixtures)")
+	mockCmd.Flags().IntVarP(&mockPort, "port", "p", 8080, "listen port")
+	mockCmd.Flags().StringVarP(&mockScenario, "scenario", "s", "", "initial scenario, e.g. session-expired, username-cooldown, rate-limited")
+	mockCmd.Flags().StringVar(&mockAddress, "address", "", "treat requests without a mock session as logged in with this address")
+}
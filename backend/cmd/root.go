@@ -9,6 +9,9 @@ import (
 
 var configPath string
 
+// configOverrides 命令行 --set 传入的配置，优先级高于配置文件和环境变量
+var configOverrides []string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "beast-royale-backend",
@@ -27,4 +30,5 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "config file path")
+	rootCmd.PersistentFlags().StringArrayVar(&configOverrides, "set", nil, "override a config value, e.g. --set server.port=9090 (repeatable)")
 }
@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/audit"
 	"beast-royale-backend/internal/config"
 	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/eventbus"
+	"beast-royale-backend/internal/health"
+	"beast-royale-backend/internal/lifecycle"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/internal/redispool"
+	"beast-royale-backend/internal/tracing"
+	"beast-royale-backend/internal/version"
 	"beast-royale-backend/server"
 
 	"github.com/spf13/cobra"
@@ -20,42 +31,182 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "run Beast Royale Backend service",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := config.InitConfig(configPath)
+		err := runServer()
 		if err != nil {
-			fmt.Printf("load config failed: %+v\n", err)
+			fmt.Printf("%+v\n", err)
 			os.Exit(-1)
 		}
+	},
+}
 
-		// 初始化日志系统
-		err = logger.Init()
-		if err != nil {
-			fmt.Printf("init logger failed: %+v\n", err)
-			os.Exit(-1)
+// runServer 初始化各组件并启动服务，直到收到退出信号或服务异常退出
+// 组件初始化成功后注册到lifecycle，无论正常退出还是启动失败，都按相反顺序关闭已初始化的组件
+func runServer() (err error) {
+	err = config.InitConfig(configPath, configOverrides...)
+	if err != nil {
+		return fmt.Errorf("load config failed: %w", err)
+	}
+
+	cfg := config.Get()
+
+	// 初始化日志系统
+	err = logger.Init(cfg.Logging)
+	if err != nil {
+		return fmt.Errorf("init logger failed: %w", err)
+	}
+
+	lc := lifecycle.NewManager()
+	defer func() {
+		stopErr := lc.Stop()
+		if err == nil && stopErr != nil {
+			err = fmt.Errorf("shutdown failed: %w", stopErr)
 		}
+	}()
 
-		// 确保API包被初始化（调用init函数）
-		_ = api.GetAllAction()
+	shutdownTracing, err := tracing.Init(cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("init tracing failed: %w", err)
+	}
+	// 最后关闭，导出剩余的span
+	lc.OnStop("tracing", 5*time.Second, shutdownTracing)
 
-		err = db.Init()
-		if err != nil {
-			fmt.Printf("init db failed: %+v\n", err)
-			os.Exit(-1)
+	// 确保API包被初始化（调用init函数）
+	_ = api.GetAllAction()
+
+	err = db.Init()
+	if err != nil {
+		return fmt.Errorf("init db failed: %w", err)
+	}
+	lc.OnStop("database", 0, func(ctx context.Context) error {
+		return db.Close()
+	})
+	// 启动时不迁移表结构，只提示未执行的迁移；内存数据库每次启动都是空的，直接执行所有迁移
+	if cfg.InMemoryDatabase() {
+		if err := migrateInMemory(cfg); err != nil {
+			return fmt.Errorf("migrate in-memory database failed: %w", err)
 		}
-		fmt.Println("Using config file:", configPath)
+	} else {
+		warnPendingMigrations(cfg)
+	}
 
-		s := server.NewServer(config.GConf)
-		go s.Run()
-		fmt.Printf("http server running on: 0.0.0.0:%d\n", config.GConf.Server.Port)
+	// 任务通过仓库接口访问数据库，创建服务时传给每个任务
+	store := db.NewStore(db.GetDB())
+	deps := &api.Deps{Store: store}
 
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-		sig := <-sigCh
-		fmt.Printf("signal received: %s, server shutting down\n", sig)
-		err = s.Stop()
+	// 按保留期定期清理过期的审计记录，retention_days为-1时不清理
+	if pruner := audit.NewPruner(store.AuditEvents(), &cfg.Audit); pruner != nil {
+		pruneCtx, stopPrune := context.WithCancel(context.Background())
+		go pruner.Run(pruneCtx)
+		lc.OnStop("audit pruner", 0, func(ctx context.Context) error {
+			stopPrune()
+			return nil
+		})
+	}
+
+	// session、限流和事件总线都不使用Redis时不连接Redis
+	if cfg.UsesRedis() {
+		err = redispool.Init(cfg)
 		if err != nil {
-			fmt.Printf("error shutting down server: %+v\n", err)
+			return fmt.Errorf("init redis failed: %w", err)
 		}
-	},
+		lc.OnStop("redis", 0, func(ctx context.Context) error {
+			return redispool.Close()
+		})
+	}
+
+	// 依赖初始化成功后注册就绪探测
+	// 数据库探测以驱动命名，MySQL部署的组件名保持为mysql
+	health.DefaultChecker.AddProbe(cfg.Database.Driver, db.Ping)
+	if cfg.UsesRedis() {
+		health.DefaultChecker.AddProbe("redis", redispool.Ping)
+	}
+
+	err = eventbus.Init(cfg, push.DefaultHub)
+	if err != nil {
+		return fmt.Errorf("init event bus failed: %w", err)
+	}
+	lc.OnStop("event bus", 0, func(ctx context.Context) error {
+		return eventbus.Close()
+	})
+	fmt.Println("Using config file:", configPath)
+	fmt.Printf("version: %s, commit: %s\n", version.Version, version.Commit)
+
+	s, err := server.NewServer(cfg, deps)
+	if err != nil {
+		return fmt.Errorf("init http server failed: %w", err)
+	}
+	err = s.Start()
+	if err != nil {
+		return fmt.Errorf("start http server failed: %w", err)
+	}
+	// 排空时间包括drain_delay和等待进行中请求的时间
+	drainTimeout := time.Duration(cfg.Server.DrainDelay+cfg.Server.ShutdownTimeout) * time.Second
+	lc.OnStop("http server", drainTimeout, s.Stop)
+	fmt.Printf("http server running on: %s\n", cfg.GetServerAddr())
+
+	// 配置重新加载后更新日志和请求处理策略
+	config.Default.Subscribe("logging", func(cfg *config.Config) error {
+		return logger.Reload(cfg.Logging)
+	})
+	config.Default.Subscribe("http server", func(cfg *config.Config) error {
+		s.Reload(cfg)
+		return nil
+	})
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	err = config.Default.Watch(watchCtx, func() { reloadConfig("file change") })
+	if err != nil {
+		// 不影响服务，仍可以通过SIGHUP重新加载
+		logger.Warn("监听配置文件失败，只能通过SIGHUP重新加载配置 - Error: %v", err)
+	}
+	lc.OnStop("config watcher", 0, func(ctx context.Context) error {
+		stopWatch()
+		return nil
+	})
+
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	for running := true; running; {
+		select {
+		case <-hupCh:
+			reloadConfig("SIGHUP")
+		case sig := <-sigCh:
+			fmt.Printf("signal received: %s, server shutting down\n", sig)
+			running = false
+		case err = <-s.Err():
+			return fmt.Errorf("http server exited: %w", err)
+		}
+	}
+	signal.Stop(hupCh)
+
+	// 关闭过程中再次收到信号时立即退出
+	go func() {
+		sig := <-sigCh
+		fmt.Printf("signal received again: %s, exit immediately\n", sig)
+		os.Exit(-1)
+	}()
+	return nil
+}
+
+// reloadConfig 重新加载配置并记录结果，失败时继续使用当前配置
+func reloadConfig(trigger string) {
+	result, err := config.Default.Reload()
+	if result == nil {
+		logger.Error("重新加载配置失败，继续使用当前配置 - Trigger: %s, Error: %v", trigger, err)
+		return
+	}
+	if err != nil {
+		logger.Error("部分配置应用失败 - Trigger: %s, Error: %v", trigger, err)
+	}
+	if len(result.RestartRequired) > 0 {
+		logger.Warn("以下配置需要重启才能生效，当前继续使用旧值 - Trigger: %s, Keys: %s", trigger, strings.Join(result.RestartRequired, ", "))
+	}
+	if len(result.Reloaded) > 0 {
+		logger.Info("配置已重新加载 - Trigger: %s, Keys: %s", trigger, strings.Join(result.Reloaded, ", "))
+	} else if len(result.RestartRequired) == 0 {
+		logger.Info("配置没有变化 - Trigger: %s", trigger)
+	}
 }
 
 func init() {
@@ -63,3 +214,35 @@ func init() {
 	runCmd.Flags().StringVarP(&configPath, "config", "c", "", "config file path")
 	runCmd.MarkFlagRequired("config")
 }
+
+// migrateInMemory 在内存数据库上执行所有迁移
+func migrateInMemory(cfg *config.Config) error {
+	m, err := db.NewMigrator(db.GetDB(), cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+	done, err := m.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	logger.Info("内存数据库已迁移 - Migrations: %d", len(done))
+	return nil
+}
+
+// warnPendingMigrations 表结构落后于当前版本时输出警告，提示先执行 db-migrate up
+// 只读取schema_migrations，不修改表结构
+func warnPendingMigrations(cfg *config.Config) {
+	m, err := db.NewMigrator(db.GetDB(), cfg.Database.Driver)
+	if err != nil {
+		logger.Warn("加载数据库迁移失败: %v", err)
+		return
+	}
+	pending, err := m.Pending(context.Background())
+	if err != nil {
+		logger.Warn("检查数据库迁移失败: %v", err)
+		return
+	}
+	for _, mig := range pending {
+		logger.Warn("数据库迁移未执行，请先运行 db-migrate up - Version: %d, Name: %s", mig.Version, mig.Name)
+	}
+}
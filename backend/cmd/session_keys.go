@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/sessionkeys"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyringPath  string
+	keyringForce bool
+	rotateStaged bool
+	rotateKeep   int
+)
+
+// sessionKeysCmd represents the session-keys command
+var sessionKeysCmd = &cobra.Command{
+	Use:   "session-keys",
+	Short: "generate and rotate the session cookie keyring",
+	Long: `Manage the keyring file referenced by security.session_keyring.
+The first key signs new session cookies and every key verifies them, so rotating
+keeps existing sessions valid; cookies signed by an older key are re-issued with
+the current key on the next request. The service loads the keyring at startup,
+so restart it after changing the file.
+
+For a single instance:   session-keys rotate
+For several instances:   session-keys rotate --staged, deploy everywhere,
+                         then session-keys promote <id> and deploy again`,
+}
+
+var sessionKeysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "create a new keyring with one random key",
+	Run: func(cmd *cobra.Command, args []string) {
+		path := mustKeyringPath()
+		if _, err := os.Stat(path); err == nil && !keyringForce {
+			fmt.Printf("%s already exists, use rotate to add a key or --force to overwrite\n", path)
+			os.Exit(-1)
+		}
+		key, err := sessionkeys.NewKey()
+		if err != nil {
+			fmt.Printf("generate key failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		keyring := &sessionkeys.Keyring{Keys: []sessionkeys.Key{key}}
+		if err := keyring.Save(path); err != nil {
+			fmt.Printf("save keyring failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Printf("keyring written to %s, signing key: %s\n", path, key.ID)
+	},
+}
+
+var sessionKeysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "add a new key, signing with it unless --staged",
+	Run: func(cmd *cobra.Command, args []string) {
+		path := mustKeyringPath()
+		keyring := mustLoadKeyring(path)
+		key, err := keyring.Rotate(rotateStaged, rotateKeep)
+		if err != nil {
+			fmt.Printf("rotate keyring failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		if err := keyring.Save(path); err != nil {
+			fmt.Printf("save keyring failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		if rotateStaged {
+			fmt.Printf("added verify-only key %s, run `session-keys promote %s` after every instance has restarted\n", key.ID, key.ID)
+		} else {
+			fmt.Printf("new signing key: %s, restart the service to apply\n", key.ID)
+		}
+		printKeyring(keyring)
+	},
+}
+
+var sessionKeysPromoteCmd = &cobra.Command{
+	Use:   "promote <id>",
+	Short: "make a staged key the signing key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := mustKeyringPath()
+		keyring := mustLoadKeyring(path)
+		if err := keyring.Promote(args[0]); err != nil {
+			fmt.Printf("promote key failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		if err := keyring.Save(path); err != nil {
+			fmt.Printf("save keyring failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Printf("signing key: %s, restart the service to apply\n", args[0])
+		printKeyring(keyring)
+	},
+}
+
+var sessionKeysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list key ids without printing the keys",
+	Run: func(cmd *cobra.Command, args []string) {
+		printKeyring(mustLoadKeyring(mustKeyringPath()))
+	},
+}
+
+// mustKeyringPath 优先使用--keyring，否则使用配置中的security.session_keyring
+func mustKeyringPath() string {
+	if keyringPath != "" {
+		return keyringPath
+	}
+	if configPath != "" {
+		cfg, err := config.Load(configPath, configOverrides...)
+		if err != nil {
+			fmt.Printf("load config failed: %+v\n", err)
+			os.Exit(-1)
+		}
+		if cfg.Security.SessionKeyring != "" {
+			return cfg.Security.SessionKeyring
+		}
+	}
+	fmt.Println(errors.New("keyring path is required: pass --keyring or set security.session_keyring in the config"))
+	os.Exit(-1)
+	return ""
+}
+
+func mustLoadKeyring(path string) *sessionkeys.Keyring {
+	keyring, err := sessionkeys.Load(path)
+	if err != nil {
+		fmt.Printf("load keyring failed: %+v\n", err)
+		os.Exit(-1)
+	}
+	return keyring
+}
+
+func printKeyring(keyring *sessionkeys.Keyring) {
+	for i, key := range keyring.Keys {
+		role := "verify"
+		if i == 0 {
+			role = "sign"
+		}
+		fmt.Printf("%-20s %-7s created %s\n", key.ID, role, key.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func init() {
+	sessionKeysCmd.PersistentFlags().StringVar(&keyringPath, "keyring", "", "keyring file (default security.session_keyring from --config)")
+	sessionKeysGenerateCmd.Flags().BoolVar(&keyringForce, "force", false, "overwrite an existing keyring")
+	sessionKeysRotateCmd.Flags().BoolVar(&rotateStaged, "staged", false, "add the key for verification only, promote it later")
+	sessionKeysRotateCmd.Flags().IntVar(&rotateKeep, "keep", 2, "number of previous keys to keep for verification, -1 keeps all")
+
+	sessionKeysCmd.AddCommand(sessionKeysGenerateCmd, sessionKeysRotateCmd, sessionKeysPromoteCmd, sessionKeysListCmd)
+	rootCmd.AddCommand(sessionKeysCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+
+	"beast-royale-backend/internal/version"
+
+	"github.com/spf13/cobra"
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "print the build version and commit",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("version: %s\ncommit: %s\n", version.Version, version.Commit)
+		if version.BuildTime != "" {
+			fmt.Printf("built: %s\n", version.BuildTime)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
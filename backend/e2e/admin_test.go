@@ -0,0 +1,140 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"beast-royale-backend/internal/admin"
+	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/testharness"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAdminSuspend(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+
+	conn, _, err := c.DialWebSocket()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	users := admin.NewUsers(db.NewStore(h.DB), admin.Operator{Actor: "test"})
+	if _, err := users.Suspend(context.Background(), w.Address); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	// 已登录的session不能再调用任何需要登录的Action，在认证时检查而不是由各个Action检查
+	c.Call("GetUserProfile", nil).ExpectRetCode(t, 403)
+	c.Call("UpdateUserProfile", map[string]interface{}{"Bio": "blocked"}).ExpectRetCode(t, 403)
+	if resp := c.Do(http.MethodGet, "/api/v1/profile", nil); resp.Status != http.StatusForbidden {
+		t.Fatalf("REST profile: status %d, want 403", resp.Status)
+	}
+
+	// 不能建立新的WebSocket连接，已有的连接在下一条帧时断开
+	if _, resp, err := c.DialWebSocket(); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("dial after suspend: err %v, response %v", err, resp)
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"Action": "Subscribe", "Topic": "room:1"}); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	expectClosed(t, conn, websocket.ClosePolicyViolation)
+
+	// 不能重新登录
+	other := h.NewClient()
+	nonce := other.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0).Int("nonce")
+	other.Call("VerifySignature", map[string]interface{}{
+		"Address":   w.Address,
+		"Signature": w.SignLogin(nonce),
+		"Nonce":     nonce,
+	}).ExpectRetCode(t, 403)
+
+	if _, err := users.Unsuspend(context.Background(), w.Address); err != nil {
+		t.Fatalf("unsuspend: %v", err)
+	}
+	h.NewClient().Login(w)
+	c.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+}
+
+func TestAdminSuspendClosesIdleWebSocket(t *testing.T) {
+	h := testharness.New(t, testharness.WithOverrides("websocket.ping_interval=1"))
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+	conn, _, err := c.DialWebSocket()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	users := admin.NewUsers(db.NewStore(h.DB), admin.Operator{Actor: "test"})
+	if _, err := users.Suspend(context.Background(), w.Address); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	// 客户端不发送帧时，下一次心跳发现账户已被封禁，不再接收推送
+	expectClosed(t, conn, websocket.ClosePolicyViolation)
+}
+
+// expectClosed 等待服务端以code关闭WebSocket连接，期间收到的其他消息被忽略
+func expectClosed(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, code) {
+			t.Fatalf("read: %v, want close %d", err, code)
+		}
+		return
+	}
+}
+
+func TestAdminGrantTokens(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+	store := db.NewStore(h.DB)
+	ctx := context.Background()
+
+	// DryRun返回变化但不保存
+	dry := admin.NewUsers(store, admin.Operator{Actor: "test", DryRun: true})
+	result, err := dry.GrantTokens(ctx, w.Address, 250)
+	if err != nil {
+		t.Fatalf("dry run grant: %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].From != "1000" || result.Changes[0].To != "1250" {
+		t.Fatalf("unexpected changes: %+v", result.Changes)
+	}
+	if tokens := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0).Int("tokens"); tokens != 1000 {
+		t.Fatalf("dry run saved tokens: %d", tokens)
+	}
+	// DryRun在事务外留下一条审计记录
+	events, err := store.AuditEvents().Find(ctx, db.AuditFilter{Action: "admin user grant-tokens --dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Actor != "test" || events[0].Target != w.LowerAddress() || len(events[0].Changes) != 1 {
+		t.Fatalf("unexpected dry run audit events: %+v", events)
+	}
+
+	users := admin.NewUsers(store, admin.Operator{Actor: "test"})
+	if _, err := users.GrantTokens(ctx, w.Address, 250); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if tokens := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0).Int("tokens"); tokens != 1250 {
+		t.Fatalf("tokens = %d, want 1250", tokens)
+	}
+	if _, err := users.GrantTokens(ctx, w.Address, -2000); !errors.Is(err, admin.ErrNegativeBalance) {
+		t.Fatalf("deduct below zero: err = %v, want ErrNegativeBalance", err)
+	}
+}
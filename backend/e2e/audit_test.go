@@ -0,0 +1,207 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"beast-royale-backend/internal/admin"
+	"beast-royale-backend/internal/audit"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/testharness"
+)
+
+// newAuditHarness 启动配置了管理密钥ops的服务，返回带有该密钥的客户端
+func newAuditHarness(t *testing.T) (*testharness.Harness, *testharness.Client) {
+	key, hash, err := admin.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate admin key: %v", err)
+	}
+	h := testharness.New(t, testharness.WithConfig(func(cfg *config.Config) {
+		cfg.Security.AdminAPIKeys = map[string]string{"ops": hash}
+	}))
+	ops := h.NewClient()
+	ops.Header.Set(admin.ADMIN_KEY_HEADER, key)
+	return h, ops
+}
+
+// auditEvents 取出GetAuditTrail响应中的记录
+func auditEvents(t *testing.T, resp *testharness.Response) []map[string]interface{} {
+	t.Helper()
+	list, ok := resp.Body["events"].([]interface{})
+	if !ok {
+		t.Fatalf("events missing: %s", resp.Raw)
+	}
+	events := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		events = append(events, item.(map[string]interface{}))
+	}
+	return events
+}
+
+func TestAuditTrail(t *testing.T) {
+	h, ops := newAuditHarness(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+
+	c.Call("UpdateUserProfile", map[string]interface{}{
+		"RequestUUID": "update-1",
+		"Username":    "alice",
+	}).ExpectRetCode(t, 0)
+	// DryRun回滚时审计记录一起回滚
+	c.Call("UpdateUserProfile", map[string]interface{}{"Bio": "not saved", "DryRun": true}).ExpectRetCode(t, 0)
+	// 没有修改任何字段时不记录
+	c.Call("UpdateUserProfile", map[string]interface{}{"Username": "alice"}).ExpectRetCode(t, 0)
+
+	users := admin.NewUsers(db.NewStore(h.DB), admin.Operator{Actor: "carol", Reason: "refund"})
+	if _, err := users.GrantTokens(context.Background(), "alice", 50); err != nil {
+		t.Fatalf("grant tokens: %v", err)
+	}
+
+	events := auditEvents(t, ops.Call("GetAuditTrail", map[string]interface{}{"Target": w.Address}).ExpectRetCode(t, 0))
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %v", len(events), events)
+	}
+	// 从新到旧
+	grant, update, created := events[0], events[1], events[2]
+	if grant["actor_type"] != dao.ACTOR_ADMIN || grant["actor"] != "carol" || grant["reason"] != "refund" ||
+		grant["action"] != "admin user grant-tokens" {
+		t.Fatalf("unexpected admin event: %v", grant)
+	}
+	if update["actor_type"] != dao.ACTOR_ADDRESS || update["actor"] != w.LowerAddress() ||
+		update["action"] != "UpdateUserProfile" || update["request_uuid"] != "update-1" || update["ip"] == nil {
+		t.Fatalf("unexpected update event: %v", update)
+	}
+	changes := update["changes"].([]interface{})
+	if len(changes) != 1 || changes[0].(map[string]interface{})["to"] != "alice" {
+		t.Fatalf("unexpected update changes: %v", changes)
+	}
+	if created["action"] != "VerifySignature" || created["target"] != w.LowerAddress() {
+		t.Fatalf("unexpected login event: %v", created)
+	}
+
+	// 过滤
+	byAction := auditEvents(t, ops.Call("GetAuditTrail", map[string]interface{}{"AuditAction": "UpdateUserProfile"}).ExpectRetCode(t, 0))
+	if len(byAction) != 1 || byAction[0]["request_uuid"] != "update-1" {
+		t.Fatalf("filter by action: %v", byAction)
+	}
+	byActor := auditEvents(t, ops.Call("GetAuditTrail", map[string]interface{}{"ActorType": "admin", "Actor": "carol"}).ExpectRetCode(t, 0))
+	if len(byActor) != 1 {
+		t.Fatalf("filter by actor: %v", byActor)
+	}
+	future := time.Now().Add(time.Hour).Unix()
+	if later := auditEvents(t, ops.Call("GetAuditTrail", map[string]interface{}{"Since": future}).ExpectRetCode(t, 0)); len(later) != 0 {
+		t.Fatalf("filter by since: %v", later)
+	}
+
+	// 分页
+	page := ops.Call("GetAuditTrail", map[string]interface{}{"Target": w.Address, "Limit": 2}).ExpectRetCode(t, 0)
+	if len(auditEvents(t, page)) != 2 || page.Int("next_before_id") == 0 {
+		t.Fatalf("first page: %s", page.Raw)
+	}
+	rest := ops.Call("GetAuditTrail", map[string]interface{}{"Target": w.Address, "BeforeID": page.Int("next_before_id")}).ExpectRetCode(t, 0)
+	if last := auditEvents(t, rest); len(last) != 1 || last[0]["action"] != "VerifySignature" || rest.Int("next_before_id") != 0 {
+		t.Fatalf("second page: %s", rest.Raw)
+	}
+
+	// REST路由
+	resp := ops.Do(http.MethodGet, "/api/v1/admin/audit?action=VerifySignature&limit=5", nil)
+	if resp.Status != http.StatusOK || len(auditEvents(t, resp)) != 1 {
+		t.Fatalf("REST audit trail: %d %s", resp.Status, resp.Raw)
+	}
+}
+
+func TestAuditIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		// 未配置trusted_proxies时X-Forwarded-For被忽略，记录连接的对端地址
+		{name: "untrusted", want: "127.0.0.1"},
+		// 请求来自受信任的代理时记录代理传入的客户端地址
+		{name: "trusted proxy", proxies: []string{"127.0.0.1"}, want: "203.0.113.7"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testharness.New(t, testharness.WithConfig(func(cfg *config.Config) {
+				cfg.Server.TrustedProxies = tt.proxies
+			}))
+			c := h.NewClient()
+			w := testharness.NewWallet(t)
+			c.Login(w)
+			c.Header.Set("X-Forwarded-For", "203.0.113.7")
+			c.Call("UpdateUserProfile", map[string]interface{}{"Bio": "hello"}).ExpectRetCode(t, 0)
+
+			events, err := db.NewStore(h.DB).AuditEvents().Find(context.Background(), db.AuditFilter{Action: "UpdateUserProfile"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 || events[0].IP != tt.want {
+				t.Fatalf("audit IP = %+v, want %s", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditTrailRequiresAdminKey(t *testing.T) {
+	h, _ := newAuditHarness(t)
+
+	// 玩家的session不能访问
+	player := h.NewClient()
+	player.Login(testharness.NewWallet(t))
+	player.Call("GetAuditTrail", nil).ExpectRetCode(t, 401)
+
+	wrong := h.NewClient()
+	wrong.Header.Set(admin.ADMIN_KEY_HEADER, "not-a-key")
+	wrong.Call("GetAuditTrail", nil).ExpectRetCode(t, 401)
+
+	if resp := h.NewClient().Do(http.MethodGet, "/api/v1/admin/audit", nil); resp.Status != http.StatusUnauthorized {
+		t.Fatalf("REST without key: status %d", resp.Status)
+	}
+}
+
+func TestAuditRetention(t *testing.T) {
+	h, _ := newAuditHarness(t)
+	store := db.NewStore(h.DB)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, age := range []time.Duration{400 * 24 * time.Hour, 366 * 24 * time.Hour, 10 * 24 * time.Hour, 0} {
+		event := &dao.AuditEvent{
+			CreatedAt: now.Add(-age),
+			ActorType: dao.ACTOR_ADMIN,
+			Actor:     "test",
+			Action:    "seed",
+			Target:    fmt.Sprintf("event-%d", i),
+		}
+		if err := store.AuditEvents().Append(ctx, event); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	pruner := audit.NewPruner(store.AuditEvents(), &config.AuditConfig{RetentionDays: 365, PruneInterval: 60}).
+		WithClock(func() time.Time { return now })
+	deleted, err := pruner.Prune(ctx)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted %d events, want 2", deleted)
+	}
+	left, err := store.AuditEvents().Find(ctx, db.AuditFilter{})
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(left) != 2 || left[0].Target != "event-3" || left[1].Target != "event-2" {
+		t.Fatalf("unexpected remaining events: %+v", left)
+	}
+
+	if audit.NewPruner(store.AuditEvents(), &config.AuditConfig{RetentionDays: -1}) != nil {
+		t.Fatal("retention_days -1 should disable pruning")
+	}
+}
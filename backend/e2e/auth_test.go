@@ -0,0 +1,173 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/lifecycle"
+	"beast-royale-backend/internal/testharness"
+	"beast-royale-backend/server"
+)
+
+func TestLoginFlow(t *testing.T) {
+	for _, store := range []string{"redis", "memory", "cookie"} {
+		t.Run(store, func(t *testing.T) {
+			h := testharness.New(t, testharness.WithSessionStore(store))
+			c := h.NewClient()
+			w := testharness.NewWallet(t)
+
+			// 同一个会话重复ConnectWallet返回同一个nonce
+			first := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0)
+			second := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0)
+			if first.Int("nonce") != second.Int("nonce") {
+				t.Fatalf("nonce changed within a session: %d != %d", first.Int("nonce"), second.Int("nonce"))
+			}
+
+			nonce := first.Int("nonce")
+			verified := c.Call("VerifySignature", map[string]interface{}{
+				"Address":   w.Address,
+				"Signature": w.SignLogin(nonce),
+				"Nonce":     nonce,
+			}).ExpectRetCode(t, 0)
+			if verified.String("token") == "" {
+				t.Fatalf("token is empty: %s", verified.Raw)
+			}
+
+			// 首次登录自动创建档案，用户名与小写地址相同
+			profile := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+			if profile.String("address") != w.LowerAddress() || profile.String("username") != w.LowerAddress() {
+				t.Fatalf("unexpected profile: %s", profile.Raw)
+			}
+			if profile.Int("tokens") != 1000 {
+				t.Fatalf("tokens = %d, want 1000", profile.Int("tokens"))
+			}
+
+			c.Call("Logout", nil).ExpectRetCode(t, 0)
+			resp := c.Call("GetUserProfile", nil)
+			if resp.Status != http.StatusUnauthorized {
+				t.Fatalf("GetUserProfile after logout: status %d, response: %s", resp.Status, resp.Raw)
+			}
+		})
+	}
+}
+
+func TestLoginIsPerSession(t *testing.T) {
+	h := testharness.New(t)
+	w := testharness.NewWallet(t)
+	h.NewClient().Login(w)
+
+	// 另一个浏览器没有session cookie
+	resp := h.NewClient().Call("GetUserProfile", nil)
+	if resp.Status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401, response: %s", resp.Status, resp.Raw)
+	}
+}
+
+func TestVerifySignatureRejects(t *testing.T) {
+	h := testharness.New(t)
+	w := testharness.NewWallet(t)
+	other := testharness.NewWallet(t)
+
+	tests := []struct {
+		name    string
+		sign    func(nonce int) (signature string, sentNonce int)
+		message string
+	}{
+		{
+			name:    "signed by another key",
+			sign:    func(nonce int) (string, int) { return other.SignLogin(nonce), nonce },
+			message: "Invalid signature",
+		},
+		{
+			name:    "wrong nonce",
+			sign:    func(nonce int) (string, int) { return w.SignLogin(nonce + 1), nonce + 1 },
+			message: "Invalid nonce",
+		},
+		{
+			name:    "malformed signature",
+			sign:    func(nonce int) (string, int) { return "0x1234", nonce },
+			message: "Signature verification failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := h.NewClient()
+			nonce := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0).Int("nonce")
+			signature, sentNonce := tt.sign(nonce)
+			resp := c.Call("VerifySignature", map[string]interface{}{
+				"Address":   w.Address,
+				"Signature": signature,
+				"Nonce":     sentNonce,
+			}).ExpectRetCode(t, 401)
+			if resp.String("Message") != tt.message {
+				t.Fatalf("Message = %q, want %q", resp.String("Message"), tt.message)
+			}
+			if resp := c.Call("GetUserProfile", nil); resp.Status != http.StatusUnauthorized {
+				t.Fatalf("GetUserProfile after failed login: status %d", resp.Status)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureWithoutNonce(t *testing.T) {
+	h := testharness.New(t)
+	w := testharness.NewWallet(t)
+
+	// 没有先调用ConnectWallet
+	h.NewClient().Call("VerifySignature", map[string]interface{}{
+		"Address":   w.Address,
+		"Signature": w.SignLogin(123456),
+		"Nonce":     123456,
+	}).ExpectRetCode(t, 401)
+}
+
+func TestNonceIsSingleUse(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+
+	nonce := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0).Int("nonce")
+	params := map[string]interface{}{"Address": w.Address, "Signature": w.SignLogin(nonce), "Nonce": nonce}
+	c.Call("VerifySignature", params).ExpectRetCode(t, 0)
+	c.Call("VerifySignature", params).ExpectRetCode(t, 401)
+}
+
+func TestRESTLogin(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+
+	connected := c.Do(http.MethodPost, "/api/v1/wallet/connect", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0)
+	nonce := connected.Int("nonce")
+	c.Do(http.MethodPost, "/api/v1/wallet/verify", map[string]interface{}{
+		"Address":   w.Address,
+		"Signature": w.SignLogin(nonce),
+		"Nonce":     nonce,
+	}).ExpectRetCode(t, 0)
+
+	profile := c.Do(http.MethodGet, "/api/v1/profile", nil).ExpectRetCode(t, 0)
+	if profile.Status != http.StatusOK || profile.String("address") != w.LowerAddress() {
+		t.Fatalf("unexpected profile: %d %s", profile.Status, profile.Raw)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	c.Call("HealthCheck", nil).ExpectRetCode(t, 0)
+	if resp := c.Do(http.MethodGet, "/api/v1/health", nil); resp.Status != http.StatusOK {
+		t.Fatalf("status = %d, response: %s", resp.Status, resp.Raw)
+	}
+}
+
+func TestNewRouterRequiresDeps(t *testing.T) {
+	h := testharness.New(t)
+	// 没有传入任务依赖时创建路由失败，而不是在第一个请求访问数据库时panic
+	if _, err := server.NewRouter(&lifecycle.Tracker{}, h.Config, nil); err == nil {
+		t.Fatal("NewRouter without deps should fail")
+	}
+	if _, err := server.NewRouter(&lifecycle.Tracker{}, h.Config, &api.Deps{}); err == nil {
+		t.Fatal("NewRouter without a store should fail")
+	}
+}
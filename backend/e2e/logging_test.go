@@ -0,0 +1,63 @@
+package e2e
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/testharness"
+)
+
+// syncBuffer 可以并发写入的日志缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRequestLogRedactsSignature(t *testing.T) {
+	if params := api.SensitiveParams("VerifySignature"); len(params) != 1 || params[0] != "Signature" {
+		t.Fatalf("SensitiveParams(VerifySignature) = %v, want [Signature]", params)
+	}
+
+	logs := &syncBuffer{}
+	h := testharness.New(t, testharness.WithOverrides("logging.level=debug"), testharness.WithLogOutput(logs))
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+
+	nonce := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address}).ExpectRetCode(t, 0).Int("nonce")
+	signature := w.SignLogin(nonce)
+	verified := c.Call("VerifySignature", map[string]interface{}{
+		"Address":   w.Address,
+		"Signature": signature,
+		"Nonce":     nonce,
+	}).ExpectRetCode(t, 0)
+
+	out := logs.String()
+	if !strings.Contains(out, "请求参数 - Action: VerifySignature") {
+		t.Fatalf("request params not logged at debug level:\n%s", out)
+	}
+	if strings.Contains(out, strings.TrimPrefix(signature, "0x")) {
+		t.Fatalf("signature written to log:\n%s", out)
+	}
+	if token := verified.String("token"); strings.Contains(out, token) {
+		t.Fatalf("token written to log:\n%s", out)
+	}
+	if !strings.Contains(out, logger.REDACTED) {
+		t.Fatalf("expected redacted fields in log:\n%s", out)
+	}
+}
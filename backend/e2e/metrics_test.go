@@ -0,0 +1,42 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestMetricsAllowlistIgnoresSpoofedForwardedFor(t *testing.T) {
+	h := testharness.New(t, testharness.WithOverrides("metrics.enabled=true", "metrics.allow_ips=10.0.0.1"))
+	c := h.NewClient()
+
+	// 连接来自127.0.0.1，伪造的X-Forwarded-For不能通过白名单
+	c.Header.Set("X-Forwarded-For", "10.0.0.1")
+	if resp := c.Do(http.MethodGet, "/metrics", nil); resp.Status != http.StatusForbidden {
+		t.Fatalf("spoofed X-Forwarded-For: status %d, want 403", resp.Status)
+	}
+	c.Header.Set("X-Real-IP", "10.0.0.1")
+	if resp := c.Do(http.MethodGet, "/metrics", nil); resp.Status != http.StatusForbidden {
+		t.Fatalf("spoofed X-Real-IP: status %d, want 403", resp.Status)
+	}
+}
+
+func TestMetricsAllowlistBehindTrustedProxy(t *testing.T) {
+	h := testharness.New(t, testharness.WithOverrides(
+		"metrics.enabled=true",
+		"metrics.allow_ips=10.0.0.1",
+		"server.trusted_proxies=127.0.0.1",
+	))
+	c := h.NewClient()
+
+	// 受信任的代理转发的客户端IP在白名单中
+	c.Header.Set("X-Forwarded-For", "10.0.0.1")
+	if resp := c.Do(http.MethodGet, "/metrics", nil); resp.Status != http.StatusOK {
+		t.Fatalf("forwarded by trusted proxy: status %d, want 200", resp.Status)
+	}
+	c.Header.Set("X-Forwarded-For", "10.0.0.2")
+	if resp := c.Do(http.MethodGet, "/metrics", nil); resp.Status != http.StatusForbidden {
+		t.Fatalf("client not in allowlist: status %d, want 403", resp.Status)
+	}
+}
@@ -0,0 +1,163 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestUpdateUserProfile(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+
+	resp := c.Call("UpdateUserProfile", map[string]interface{}{
+		"Username":  "alice",
+		"Bio":       "hello",
+		"AvatarURL": "https://example.com/avatars/" + strings.Repeat("a", 60) + ".png",
+	}).ExpectRetCode(t, 0)
+	if !resp.Bool("username_updated") || resp.String("username") != "alice" || resp.String("last_username_update") == "" {
+		t.Fatalf("username not updated: %s", resp.Raw)
+	}
+
+	profile := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+	if profile.String("username") != "alice" || profile.String("bio") != "hello" {
+		t.Fatalf("profile not saved: %s", profile.Raw)
+	}
+	// 头像地址超过旧的varchar(50)
+	if len(profile.String("avatar_url")) <= 50 {
+		t.Fatalf("avatar_url truncated: %q", profile.String("avatar_url"))
+	}
+
+	// 只修改其他字段时不受用户名冷却期限制
+	resp = c.Call("UpdateUserProfile", map[string]interface{}{"Bio": "updated"}).ExpectRetCode(t, 0)
+	if resp.Bool("username_updated") || resp.String("bio") != "updated" {
+		t.Fatalf("unexpected response: %s", resp.Raw)
+	}
+}
+
+func TestUsernameCooldownPartialSuccess(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+	c.Call("UpdateUserProfile", map[string]interface{}{"Username": "first"}).ExpectRetCode(t, 0)
+
+	// 24小时内再次修改用户名：其他字段保存，用户名不变，返回206
+	resp := c.Call("UpdateUserProfile", map[string]interface{}{"Username": "second", "Bio": "still saved"}).ExpectRetCode(t, 206)
+	if resp.Status != http.StatusOK {
+		t.Fatalf("/api status = %d, want 200", resp.Status)
+	}
+	if resp.Bool("username_updated") || resp.String("username") != "first" || resp.String("bio") != "still saved" {
+		t.Fatalf("unexpected partial success response: %s", resp.Raw)
+	}
+	profile := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+	if profile.String("username") != "first" || profile.String("bio") != "still saved" {
+		t.Fatalf("unexpected profile after partial success: %s", profile.Raw)
+	}
+
+	// REST端点同样返回206的RetCode，HTTP状态码为200
+	resp = c.Do(http.MethodPatch, "/api/v1/profile", map[string]interface{}{"Username": "third", "XUsername": "x_first"}).ExpectRetCode(t, 206)
+	if resp.Status != http.StatusOK || resp.String("x_username") != "x_first" {
+		t.Fatalf("unexpected REST partial success: %d %s", resp.Status, resp.Raw)
+	}
+
+	// 冷却期过后可以再次修改
+	past := time.Now().Add(-25 * time.Hour)
+	err := h.DB.Model(&dao.UserProfile{}).Where("address = ?", w.LowerAddress()).Update("last_username_update", past).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = c.Call("UpdateUserProfile", map[string]interface{}{"Username": "second"}).ExpectRetCode(t, 0)
+	if !resp.Bool("username_updated") || resp.String("username") != "second" {
+		t.Fatalf("username not updated after cooldown: %s", resp.Raw)
+	}
+}
+
+func TestUsernameTaken(t *testing.T) {
+	h := testharness.New(t)
+	alice, bob := h.NewClient(), h.NewClient()
+	alice.Login(testharness.NewWallet(t))
+	bobWallet := testharness.NewWallet(t)
+	bob.Login(bobWallet)
+
+	alice.Call("UpdateUserProfile", map[string]interface{}{"Username": "taken"}).ExpectRetCode(t, 0)
+	resp := bob.Call("UpdateUserProfile", map[string]interface{}{"Username": "taken", "Bio": "not saved"}).ExpectRetCode(t, 400)
+	if resp.String("Message") != "Username already taken" {
+		t.Fatalf("Message = %q", resp.String("Message"))
+	}
+
+	// 整个更新在事务中回滚，其他字段也不保存
+	profile := bob.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+	if profile.String("username") != bobWallet.LowerAddress() || profile.String("bio") != "" {
+		t.Fatalf("profile changed after rejected update: %s", profile.Raw)
+	}
+}
+
+func TestUpdateUserProfileDryRun(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+
+	resp := c.Call("UpdateUserProfile", map[string]interface{}{"Username": "dryrun", "Bio": "preview", "DryRun": true}).ExpectRetCode(t, 0)
+	if !resp.Bool("dry_run") {
+		t.Fatalf("dry_run not set: %s", resp.Raw)
+	}
+	changes, _ := resp.Body["changes"].([]interface{})
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want username and bio", resp.Body["changes"])
+	}
+
+	// DryRun不是布尔值时拒绝，不能当作普通请求提交
+	for _, dryRun := range []interface{}{"true", 1} {
+		c.Call("UpdateUserProfile", map[string]interface{}{"Username": "dryrun", "DryRun": dryRun}).ExpectRetCode(t, 400)
+	}
+
+	profile := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0)
+	if profile.String("username") != w.LowerAddress() || profile.String("bio") != "" || profile.String("last_username_update") != "" {
+		t.Fatalf("dry run saved changes: %s", profile.Raw)
+	}
+
+	// DryRun同样会检查冷却期，并以警告说明
+	c.Call("UpdateUserProfile", map[string]interface{}{"Username": "real"}).ExpectRetCode(t, 0)
+	resp = c.Call("UpdateUserProfile", map[string]interface{}{"Username": "again", "DryRun": true}).ExpectRetCode(t, 206)
+	if warnings, _ := resp.Body["warnings"].([]interface{}); len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want the cooldown warning", resp.Body["warnings"])
+	}
+}
+
+func TestUpdateUserProfileValidation(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	c.Login(testharness.NewWallet(t))
+
+	tests := map[string]map[string]interface{}{
+		"username too short": {"Username": "ab"},
+		"username too long":  {"Username": strings.Repeat("a", 21)},
+		"bio too long":       {"Bio": strings.Repeat("b", 501)},
+	}
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := c.Call("UpdateUserProfile", params).ExpectRetCode(t, 400)
+			if resp.Status != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", resp.Status)
+			}
+		})
+	}
+}
+
+func TestProfileRequiresLogin(t *testing.T) {
+	h := testharness.New(t)
+	c := h.NewClient()
+	for _, action := range []string{"GetUserProfile", "UpdateUserProfile"} {
+		if resp := c.Call(action, map[string]interface{}{"Bio": "x"}); resp.Status != http.StatusUnauthorized {
+			t.Fatalf("%s without login: status %d, response: %s", action, resp.Status, resp.Raw)
+		}
+	}
+}
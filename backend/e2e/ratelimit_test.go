@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	h := testharness.New(t,
+		testharness.WithOverrides("rate_limit.enabled=true"),
+		testharness.WithConfig(func(cfg *config.Config) {
+			cfg.RateLimit.Default.IP = &config.LimitSpec{Limit: 2, Window: 60}
+		}),
+	)
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+
+	// 未配置trusted_proxies时，每次换一个X-Forwarded-For仍然按连接的对端地址限流
+	for i := 0; i < 3; i++ {
+		c.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+		resp := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address})
+		if i < 2 {
+			resp.ExpectRetCode(t, 0)
+		} else if resp.Status != http.StatusTooManyRequests {
+			t.Fatalf("request %d: status %d, want 429, response: %s", i, resp.Status, resp.Raw)
+		}
+	}
+}
+
+func TestRateLimitAddressRejectionKeepsIPBudget(t *testing.T) {
+	h := testharness.New(t,
+		testharness.WithOverrides("rate_limit.enabled=true"),
+		testharness.WithConfig(func(cfg *config.Config) {
+			cfg.RateLimit.Default.IP = &config.LimitSpec{Limit: 3, Window: 60}
+			cfg.RateLimit.Default.Address = &config.LimitSpec{Limit: 1, Window: 60}
+		}),
+	)
+	c := h.NewClient()
+	connect := func(w *testharness.Wallet) *testharness.Response {
+		return c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address})
+	}
+
+	limited := testharness.NewWallet(t)
+	connect(limited).ExpectRetCode(t, 0)
+	// 按地址被拒绝的请求归还IP的额度
+	for i := 0; i < 3; i++ {
+		if resp := connect(limited); resp.Status != http.StatusTooManyRequests {
+			t.Fatalf("address-limited request %d: status %d, response: %s", i, resp.Status, resp.Raw)
+		}
+	}
+
+	// IP额度只被允许的请求占用，还可以为另外两个地址各请求一次
+	connect(testharness.NewWallet(t)).ExpectRetCode(t, 0)
+	connect(testharness.NewWallet(t)).ExpectRetCode(t, 0)
+	if resp := connect(testharness.NewWallet(t)); resp.Status != http.StatusTooManyRequests {
+		t.Fatalf("IP limit: status %d, want 429, response: %s", resp.Status, resp.Raw)
+	}
+}
@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/mock"
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestRecordingRedactsCredentials(t *testing.T) {
+	dir := t.TempDir()
+	h := testharness.New(t, testharness.WithOverrides("recording.enabled=true", "recording.dir="+dir))
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	verified := c.Login(w)
+
+	files, err := filepath.Glob(filepath.Join(dir, "VerifySignature", "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("VerifySignature fixtures: %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if token := verified.String("token"); token == "" || strings.Contains(string(data), token) {
+		t.Fatalf("token written to fixture: %s", data)
+	}
+	var fixture mock.Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("parse fixture: %v", err)
+	}
+	if fixture.Request["Signature"] != logger.REDACTED || fixture.Response["token"] != logger.REDACTED {
+		t.Fatalf("signature and token should be redacted: %s", data)
+	}
+	if fixture.Request["Address"] != w.Address {
+		t.Fatalf("non-sensitive params should be kept: %s", data)
+	}
+}
@@ -0,0 +1,36 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestRPCBatchLimit(t *testing.T) {
+	h := testharness.New(t, testharness.WithOverrides("server.rpc_max_batch_size=2"))
+	c := h.NewClient()
+	c.Login(testharness.NewWallet(t))
+
+	notify := func(bio string) map[string]interface{} {
+		return map[string]interface{}{"jsonrpc": "2.0", "method": "UpdateUserProfile", "params": map[string]interface{}{"Bio": bio}}
+	}
+
+	// 不超过上限的批量正常执行，全部是通知时不返回内容
+	if resp := c.Do(http.MethodPost, "/rpc", []interface{}{notify("first"), notify("second")}); resp.Status != http.StatusNoContent {
+		t.Fatalf("batch within limit: status %d, response %s", resp.Status, resp.Raw)
+	}
+	if bio := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0).String("bio"); bio != "second" {
+		t.Fatalf("bio = %q, want second", bio)
+	}
+
+	// 超过上限时返回单个Invalid Request，其中的调用都不执行
+	resp := c.Do(http.MethodPost, "/rpc", []interface{}{notify("a"), notify("b"), notify("c")})
+	rpcErr, _ := resp.Body["error"].(map[string]interface{})
+	if rpcErr == nil || rpcErr["code"] != float64(-32600) || resp.Body["id"] != nil {
+		t.Fatalf("oversized batch: %s", resp.Raw)
+	}
+	if bio := c.Call("GetUserProfile", nil).ExpectRetCode(t, 0).String("bio"); bio != "second" {
+		t.Fatalf("oversized batch was executed, bio = %q", bio)
+	}
+}
@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/internal/testharness"
+)
+
+func TestWebSocketRequiresLogin(t *testing.T) {
+	h := testharness.New(t)
+	_, resp, err := h.NewClient().DialWebSocket()
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without session: err %v, response %v", err, resp)
+	}
+}
+
+func TestWebSocketOriginUsesCORSPolicy(t *testing.T) {
+	h := testharness.New(t, testharness.WithConfig(func(cfg *config.Config) {
+		cfg.CORS.AllowedOrigins = []string{"https://app.example.com", "https://*.ngrok-free.app"}
+	}))
+	c := h.NewClient()
+	c.Login(testharness.NewWallet(t))
+
+	// 与CORS使用相同的规则，通配子域名的来源也可以建立连接
+	for _, origin := range []string{"https://app.example.com", "https://abc123.ngrok-free.app"} {
+		c.Header.Set("Origin", origin)
+		conn, resp, err := c.DialWebSocket()
+		if err != nil {
+			t.Fatalf("dial from %s: err %v, response %v", origin, err, resp)
+		}
+		conn.Close()
+	}
+
+	c.Header.Set("Origin", "https://evil.example.org")
+	if _, resp, err := c.DialWebSocket(); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("dial from disallowed origin: err %v, response %v", err, resp)
+	}
+}
+
+func TestWebSocketSubscribe(t *testing.T) {
+	h := testharness.New(t, testharness.WithOverrides("websocket.max_subscriptions=2"))
+	c := h.NewClient()
+	w := testharness.NewWallet(t)
+	c.Login(w)
+
+	// 只允许订阅自己所在的房间
+	push.DefaultHub.SetTopicAuthorizer(push.ROOM_TOPIC, func(address, id string) bool {
+		return address == w.LowerAddress() && id != "private"
+	})
+	t.Cleanup(func() { push.DefaultHub.SetTopicAuthorizer(push.ROOM_TOPIC, nil) })
+
+	conn, _, err := c.DialWebSocket()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	subscribe := func(topic string) *testharness.Response {
+		return testharness.SendFrame(t, conn, map[string]interface{}{"Action": "Subscribe", "Topic": topic})
+	}
+
+	subscribe("lobby").ExpectRetCode(t, 400)
+	subscribe("room:").ExpectRetCode(t, 400)
+	subscribe("room:private").ExpectRetCode(t, 403)
+	// 没有设置权限检查的主题类型不允许订阅
+	subscribe("guild:1").ExpectRetCode(t, 403)
+
+	subscribe("room:1").ExpectRetCode(t, 0)
+	subscribe("room:1").ExpectRetCode(t, 0)
+	subscribe("room:2").ExpectRetCode(t, 0)
+	subscribe("room:3").ExpectRetCode(t, 400)
+
+	// 取消订阅后可以订阅新的主题
+	testharness.SendFrame(t, conn, map[string]interface{}{"Action": "Unsubscribe", "Topic": "room:2"}).ExpectRetCode(t, 0)
+	subscribe("room:3").ExpectRetCode(t, 0)
+
+	if n := push.DefaultHub.PushToTopic("room:3", push.NewEvent("RoomUpdated", nil)); n != 1 {
+		t.Fatalf("delivered to %d connections, want 1", n)
+	}
+	event := testharness.ReadFrame(t, conn)
+	if event.String("Event") != "RoomUpdated" || event.String("Topic") != "room:3" {
+		t.Fatalf("unexpected event: %s", event.Raw)
+	}
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// ADMIN_KEY_HEADER 调用ADMINAUTH的Action时携带密钥的请求头
+const ADMIN_KEY_HEADER = "X-Admin-Key"
+
+// GenerateKey 生成随机的管理密钥，返回密钥和写入配置的SHA-256
+func GenerateKey() (key, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = hex.EncodeToString(buf)
+	return key, HashKey(key), nil
+}
+
+// HashKey 密钥的SHA-256（hex），配置中只保存该值
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchKey 在配置的密钥中查找key，返回密钥名称
+func MatchKey(keys map[string]string, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	hash := []byte(HashKey(key))
+	for name, expected := range keys {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(expected))) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
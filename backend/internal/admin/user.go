@@ -0,0 +1,219 @@
+// Package admin 运维对玩家账户的操作，由 admin 命令调用
+//
+// 每个修改操作都在一个事务中读取、检查、保存并写入审计记录，DryRun时整个事务回滚，
+// 检查通过后在事务外另写一条Action带有 --dry-run 后缀的审计记录。
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/audit"
+	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/db"
+)
+
+var (
+	// ErrUserNotFound 地址或用户名对应的账户不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken 新用户名已被其他账户使用
+	ErrUsernameTaken = errors.New("username already taken")
+	// ErrInvalidUsername 用户名长度不符合要求，与UpdateUserProfile的校验一致
+	ErrInvalidUsername = errors.New("username must be 3 to 20 characters")
+	// ErrNegativeBalance 修改后代币或积分为负数
+	ErrNegativeBalance = errors.New("balance cannot be negative")
+	// ErrNoChange 操作不会修改任何字段，例如封禁已封禁的账户
+	ErrNoChange = errors.New("nothing to change")
+)
+
+// Operator 执行操作的人和原因，写入审计记录
+type Operator struct {
+	Actor  string
+	Reason string
+	DryRun bool
+}
+
+// Result 操作结果，Deleted为true时After为nil
+type Result struct {
+	Before  *dao.UserProfile
+	After   *dao.UserProfile
+	Changes dao.AuditChanges
+	Deleted bool
+}
+
+// Users 账户操作
+type Users struct {
+	store db.Store
+	op    Operator
+	now   func() time.Time
+}
+
+// NewUsers 创建账户操作
+func NewUsers(store db.Store, op Operator) *Users {
+	return &Users{store: store, op: op, now: time.Now}
+}
+
+// Find 按地址（0x开头）或用户名查找账户
+func (u *Users) Find(ctx context.Context, user string) (*dao.UserProfile, error) {
+	return find(ctx, u.store.UserProfiles(), user)
+}
+
+// GrantTokens 增加代币，amount为负数时扣除
+func (u *Users) GrantTokens(ctx context.Context, user string, amount int64) (*Result, error) {
+	return u.update(ctx, "grant-tokens", user, func(tx db.Store, p *dao.UserProfile) error {
+		if p.Tokens+amount < 0 {
+			return fmt.Errorf("%w: tokens %d%+d", ErrNegativeBalance, p.Tokens, amount)
+		}
+		p.Tokens += amount
+		return nil
+	})
+}
+
+// SetPoints 设置积分
+func (u *Users) SetPoints(ctx context.Context, user string, points int64) (*Result, error) {
+	return u.update(ctx, "set-points", user, func(tx db.Store, p *dao.UserProfile) error {
+		if points < 0 {
+			return fmt.Errorf("%w: points %d", ErrNegativeBalance, points)
+		}
+		p.Points = points
+		return nil
+	})
+}
+
+// Rename 修改用户名，不受24小时冷却限制，也不更新玩家自己的冷却时间
+func (u *Users) Rename(ctx context.Context, user, username string) (*Result, error) {
+	return u.update(ctx, "rename", user, func(tx db.Store, p *dao.UserProfile) error {
+		if n := len([]rune(username)); n < 3 || n > 20 {
+			return ErrInvalidUsername
+		}
+		existing, err := tx.UserProfiles().GetByUsername(ctx, username)
+		if err == nil && existing.Address != p.Address {
+			return fmt.Errorf("%w: %s", ErrUsernameTaken, existing.Address)
+		}
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+		p.Username = username
+		return nil
+	})
+}
+
+// Suspend 封禁账户，被封禁的账户不能登录，已登录的session不能再调用需要登录的Action，WebSocket连接被断开
+func (u *Users) Suspend(ctx context.Context, user string) (*Result, error) {
+	return u.update(ctx, "suspend", user, func(tx db.Store, p *dao.UserProfile) error {
+		if p.Suspended() {
+			return fmt.Errorf("%w: already suspended", ErrNoChange)
+		}
+		now := u.now()
+		p.SuspendedAt = &now
+		return nil
+	})
+}
+
+// Unsuspend 解除封禁
+func (u *Users) Unsuspend(ctx context.Context, user string) (*Result, error) {
+	return u.update(ctx, "unsuspend", user, func(tx db.Store, p *dao.UserProfile) error {
+		if !p.Suspended() {
+			return fmt.Errorf("%w: not suspended", ErrNoChange)
+		}
+		p.SuspendedAt = nil
+		return nil
+	})
+}
+
+// Delete 删除账户，玩家再次登录时会创建新的档案
+func (u *Users) Delete(ctx context.Context, user string) (*Result, error) {
+	var result *Result
+	err := u.store.RunInTx(ctx, u.op.DryRun, func(tx db.Store) error {
+		profile, err := find(ctx, tx.UserProfiles(), user)
+		if err != nil {
+			return err
+		}
+		if err := tx.UserProfiles().Delete(ctx, profile.Address); err != nil {
+			return err
+		}
+		result = &Result{Before: profile, Changes: audit.ProfileDiff(profile, nil), Deleted: true}
+		return tx.AuditEvents().Append(ctx, u.event("delete", profile.Address, result.Changes))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.recordDryRun(ctx, "delete", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// update 在事务中读取账户、执行修改并保存，与审计记录一起提交
+func (u *Users) update(ctx context.Context, command, user string, fn func(tx db.Store, p *dao.UserProfile) error) (*Result, error) {
+	var result *Result
+	err := u.store.RunInTx(ctx, u.op.DryRun, func(tx db.Store) error {
+		profile, err := find(ctx, tx.UserProfiles(), user)
+		if err != nil {
+			return err
+		}
+		before := *profile
+		if err := fn(tx, profile); err != nil {
+			return err
+		}
+		changes := audit.ProfileDiff(&before, profile)
+		if len(changes) == 0 {
+			return ErrNoChange
+		}
+		if err := tx.UserProfiles().Update(ctx, profile); err != nil {
+			return err
+		}
+		result = &Result{Before: &before, After: profile, Changes: changes}
+		return tx.AuditEvents().Append(ctx, u.event(command, profile.Address, changes))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := u.recordDryRun(ctx, command, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordDryRun DryRun时事务中的审计记录已随事务回滚，在事务外写入一条Action带有 --dry-run 后缀的记录，
+// 留下谁在什么时候检查过哪些修改；检查未通过（例如ErrNoChange）时与正式执行一样不写入
+func (u *Users) recordDryRun(ctx context.Context, command string, result *Result) error {
+	if !u.op.DryRun {
+		return nil
+	}
+	event := u.event(command+" --dry-run", result.Before.Address, result.Changes)
+	if err := u.store.AuditEvents().Append(ctx, event); err != nil {
+		return fmt.Errorf("write dry run audit event: %w", err)
+	}
+	return nil
+}
+
+// event 创建审计记录，Action为 admin 命令的名称，例如 admin user grant-tokens
+func (u *Users) event(command, target string, changes dao.AuditChanges) *dao.AuditEvent {
+	return &dao.AuditEvent{
+		ActorType: dao.ACTOR_ADMIN,
+		Actor:     u.op.Actor,
+		Action:    "admin user " + command,
+		Target:    target,
+		Changes:   changes,
+		Reason:    u.op.Reason,
+	}
+}
+
+// find 0x开头的按地址查找，其余按用户名查找
+func find(ctx context.Context, profiles db.UserProfileRepository, user string) (*dao.UserProfile, error) {
+	var profile *dao.UserProfile
+	var err error
+	if strings.HasPrefix(strings.ToLower(user), "0x") {
+		profile, err = profiles.GetByAddress(ctx, user)
+	} else {
+		profile, err = profiles.GetByUsername(ctx, user)
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, user)
+	}
+	return profile, err
+}
@@ -1,6 +1,15 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"beast-royale-backend/internal/db"
+
+	"github.com/gin-gonic/gin"
+)
 
 type AuthType uint8
 
@@ -9,13 +18,38 @@ const (
 	NOAUTH              // 无需认证
 	VERIFYAUTH          // 鉴权但不使用cookie
 	COOKIEAUTH          // 使用cookie认证
+	ADMINAUTH           // 使用管理密钥认证（X-Admin-Key）
 )
 
+func (t AuthType) String() string {
+	switch t {
+	case NOAUTH:
+		return "none"
+	case VERIFYAUTH:
+		return "verify"
+	case COOKIEAUTH:
+		return "cookie"
+	case ADMINAUTH:
+		return "admin"
+	}
+	return "unknown"
+}
+
 type Task interface {
 	Run(c *gin.Context) (Response, error)
 }
 
-type creator func(data *map[string]interface{}) (Task, error)
+// DryRunner 支持DryRun的任务：执行校验和业务检查，报告将要发生的变化，但不提交任何修改
+type DryRunner interface {
+	DryRun(c *gin.Context) (Response, error)
+}
+
+// Deps 任务依赖的数据访问等外部服务，由server.NewRouter传给每个任务，测试中可以使用db.NewMemoryStore
+type Deps struct {
+	Store db.Store
+}
+
+type creator func(data *map[string]interface{}, deps *Deps) (Task, error)
 
 type component struct {
 	creator  creator
@@ -31,8 +65,36 @@ func Register(action string, createHandler creator, authType AuthType) {
 	}
 }
 
-func NewTask(action string, data *map[string]interface{}) (Task, error) {
-	return _factory[action].creator(data)
+// Validate 检查任务需要的依赖是否都已设置，创建路由时调用，避免在处理请求时才发现
+func (d *Deps) Validate() error {
+	if d == nil || d.Store == nil {
+		return errors.New("task dependencies are not set: Deps.Store is required")
+	}
+	return nil
+}
+
+// ErrAccountSuspended 账户已被封禁
+var ErrAccountSuspended = errors.New("account suspended")
+
+// CheckAccount 检查地址是否可以登录或继续使用已登录的session，由登录、cookie认证和WebSocket调用，任务中不需要再检查
+// 账户被封禁时返回ErrAccountSuspended；档案不存在（首次登录时创建失败）视为未封禁；查询失败时返回对应的错误
+func CheckAccount(ctx context.Context, profiles db.UserProfileRepository, address string) error {
+	profile, err := profiles.GetByAddress(ctx, address)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if profile.Suspended() {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// NewTask 创建Action对应的任务并注入依赖
+func NewTask(action string, data *map[string]interface{}, deps *Deps) (Task, error) {
+	return _factory[action].creator(data, deps)
 }
 
 func GetAllAction() []string {
@@ -51,3 +113,65 @@ func Exist(action string) bool {
 func GetActionAuthType(action string) AuthType {
 	return _factory[action].authType
 }
+
+// Schema Action的请求和响应类型，用于生成客户端SDK
+type Schema struct {
+	Request  interface{} // 请求类型的零值，字段名取mapstructure标签
+	Response interface{} // 响应类型的零值，字段名取json标签
+	// Idempotent 重复执行不会产生额外的副作用，客户端可以安全重试
+	Idempotent bool
+	// DryRun 任务实现了DryRunner，客户端请求中会生成DryRun字段
+	DryRun bool
+}
+
+var _schemas = make(map[string]Schema)
+
+// _sensitiveParams 按Action记录请求结构体中带有 log:"sensitive" tag的参数名
+var _sensitiveParams = make(map[string][]string)
+
+// RegisterSchema 注册Action的请求和响应类型，通常在init中与Register一起调用
+func RegisterSchema(action string, schema Schema) {
+	_schemas[action] = schema
+	if schema.Request != nil {
+		_sensitiveParams[action] = sensitiveFields(reflect.TypeOf(schema.Request))
+	}
+}
+
+// SensitiveParams 请求结构体中带有 log:"sensitive" tag的参数名（mapstructure名称），
+// 请求参数以map的形式记录日志和录制fixture时，这些参数替换为[REDACTED]
+func SensitiveParams(action string) []string {
+	return _sensitiveParams[action]
+}
+
+// sensitiveFields 列出结构体中带有 log:"sensitive" tag的字段，包括嵌入的结构体
+func sensitiveFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			names = append(names, sensitiveFields(field.Type)...)
+			continue
+		}
+		if field.Tag.Get("log") != "sensitive" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// GetSchema 获取Action的请求和响应类型
+func GetSchema(action string) (Schema, bool) {
+	schema, ok := _schemas[action]
+	return schema, ok
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/db"
+)
+
+// failingProfiles 查询总是失败的仓库，模拟数据库不可用
+type failingProfiles struct {
+	db.UserProfileRepository
+}
+
+var errDatabaseDown = errors.New("database down")
+
+func (failingProfiles) GetByAddress(ctx context.Context, address string) (*dao.UserProfile, error) {
+	return nil, errDatabaseDown
+}
+
+func TestCheckAccount(t *testing.T) {
+	_, store := newTestDeps(t)
+	ctx := context.Background()
+	profiles := store.UserProfiles()
+
+	if err := CheckAccount(ctx, profiles, testAlice); err != nil {
+		t.Fatalf("active account: %v", err)
+	}
+	// 档案不存在（首次登录）时不阻止
+	if err := CheckAccount(ctx, profiles, "0x00000000000000000000000000000000000000c3"); err != nil {
+		t.Fatalf("missing profile: %v", err)
+	}
+
+	profile, _ := profiles.GetByAddress(ctx, testAlice)
+	now := time.Now()
+	profile.SuspendedAt = &now
+	if err := profiles.Update(ctx, profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckAccount(ctx, profiles, testAlice); !errors.Is(err, ErrAccountSuspended) {
+		t.Fatalf("suspended account: got %v, want ErrAccountSuspended", err)
+	}
+
+	// 查询失败时返回错误，由调用方拒绝请求，而不是当作未封禁放行
+	if err := CheckAccount(ctx, failingProfiles{}, testAlice); !errors.Is(err, errDatabaseDown) {
+		t.Fatalf("database error: got %v", err)
+	}
+}
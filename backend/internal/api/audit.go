@@ -0,0 +1,28 @@
+package api
+
+import (
+	"beast-royale-backend/internal/dao"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAuditEvent 创建请求对应的审计记录，由任务在修改数据的同一个事务中写入
+// 操作人优先取管理密钥名称，其次取已登录的地址
+// IP取c.ClientIP()：路由设置了SetTrustedProxies，只有来自server.trusted_proxies的请求才采用X-Forwarded-For，
+// 否则是连接的对端地址，客户端不能伪造写入审计记录的IP
+func newAuditEvent(c *gin.Context, action, target, requestUUID string, changes dao.AuditChanges) *dao.AuditEvent {
+	event := &dao.AuditEvent{
+		ActorType:   dao.ACTOR_SYSTEM,
+		Action:      action,
+		Target:      target,
+		Changes:     changes,
+		IP:          c.ClientIP(),
+		RequestUUID: requestUUID,
+	}
+	if name := c.GetString(ADMIN_KEY_NAME); name != "" {
+		event.ActorType, event.Actor = dao.ACTOR_API_KEY, name
+	} else if address := c.GetString("address"); address != "" {
+		event.ActorType, event.Actor = dao.ACTOR_ADDRESS, address
+	}
+	return event
+}
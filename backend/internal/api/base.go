@@ -69,6 +69,33 @@ func MakeErrorResponse(retCode int, message string) *BaseResponse {
 	}
 }
 
+// FieldChange DryRun时报告的一个字段的变化
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// IsDryRun 请求是否带有 DryRun: true
+// DryRun不是布尔值（例如 "true" 或 1）时返回错误，避免想要预览的请求被当作普通请求提交
+func IsDryRun(data *map[string]interface{}) (bool, error) {
+	value, ok := (*data)[DRY_RUN]
+	if !ok || value == nil {
+		return false, nil
+	}
+	dryRun, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be a boolean, got %T", DRY_RUN, value)
+	}
+	return dryRun, nil
+}
+
+// RateLimitedResponse 请求被限流时的响应
+type RateLimitedResponse struct {
+	BaseResponse
+	RetryAfter int `json:"RetryAfter"` // 建议的重试等待时间（秒）
+}
+
 func (br *BaseResponse) SetSession(session string) {
 	br.RequestUUID = session
 }
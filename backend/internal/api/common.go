@@ -16,15 +16,26 @@ const (
 	GET_USER_PROFILE_LABEL    = "GetUserProfile"
 	UPDATE_USER_PROFILE_LABEL = "UpdateUserProfile"
 	HEALTH_CHECK_LABEL        = "HealthCheck"
+	GET_AUDIT_TRAIL_LABEL     = "GetAuditTrail"
+)
+
+// ret codes
+const (
+	RATE_LIMITED_CODE        = 429 // 请求过于频繁
+	SERVICE_UNAVAILABLE_CODE = 503 // 依赖不可用或服务正在关闭
 )
 
 // param labels
 const (
 	ADDRESS      = "Address"
 	REQUEST_UUID = "RequestUUID"
+	DRY_RUN      = "DryRun"
 	Billion      = 1_000_000_000 // 10^9
 )
 
+// ADMIN_KEY_NAME 通过ADMINAUTH认证后，gin.Context中保存的管理密钥名称
+const ADMIN_KEY_NAME = "admin_key"
+
 func parseDate(dateStr string) time.Time {
 	parsedDate, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
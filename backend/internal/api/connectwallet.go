@@ -3,6 +3,7 @@ package api
 import (
 	"beast-royale-backend/internal/logger"
 	"math/rand"
+	"net/http"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -13,6 +14,19 @@ import (
 
 func init() {
 	Register(CONNECT_WALLET_LABEL, NewConnectWalletTask, NOAUTH)
+	RegisterSchema(CONNECT_WALLET_LABEL, Schema{
+		Request:    ConnectWalletRequest{},
+		Response:   ConnectWalletResponse{},
+		Idempotent: true, // 已有nonce时直接返回，可以安全重试
+	})
+	RegisterRoute(Route{
+		Method: http.MethodPost,
+		Path:   "/api/v1/wallet/connect",
+		Action: CONNECT_WALLET_LABEL,
+		Params: []RouteParam{
+			BodyParam("address", ADDRESS, STRING_KIND),
+		},
+	})
 }
 
 // ConnectWalletRequest 连接钱包请求
@@ -56,7 +70,7 @@ func NewConnectWalletResponse(sessionId string) *ConnectWalletResponse {
 }
 
 // NewConnectWalletTask 创建连接钱包任务
-func NewConnectWalletTask(data *map[string]interface{}) (Task, error) {
+func NewConnectWalletTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewConnectWalletRequest(data)
 	if err != nil {
 		return nil, err
@@ -99,19 +113,19 @@ func (task *ConnectWalletTask) Run(c *gin.Context) (Response, error) {
 		session.Set(key, nonce)
 		err := session.Save()
 		if err != nil {
-			logger.Error("保存nonce到Redis失败: %v", err)
+			logger.ErrorContext(c, "保存nonce到Redis失败: %v", err)
 			task.Response.SetRetCode(500)
 			task.Response.SetMessage("Failed to generate nonce")
 			return task.Response, nil
 		}
 
 		task.Response.Nonce = nonce
-		logger.Info("为用户 %s 生成新nonce: %d", task.Request.Address, nonce)
+		logger.InfoContext(c, "为用户 %s 生成新nonce: %d", task.Request.Address, nonce)
 	} else {
 		// 使用已有的nonce
 		nonce := v.(int)
 		task.Response.Nonce = nonce
-		logger.Info("为用户 %s 使用已有nonce: %d", task.Request.Address, nonce)
+		logger.InfoContext(c, "为用户 %s 使用已有nonce: %d", task.Request.Address, nonce)
 	}
 
 	task.Response.SetMessage("Wallet connected successfully")
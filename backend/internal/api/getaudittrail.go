@@ -0,0 +1,192 @@
+package api
+
+import (
+	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/logger"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+// DEFAULT_AUDIT_TRAIL_LIMIT 未指定Limit时每页返回的审计记录数
+const DEFAULT_AUDIT_TRAIL_LIMIT = 50
+
+func init() {
+	Register(GET_AUDIT_TRAIL_LABEL, NewGetAuditTrailTask, ADMINAUTH)
+	RegisterSchema(GET_AUDIT_TRAIL_LABEL, Schema{
+		Request:    GetAuditTrailRequest{},
+		Response:   GetAuditTrailResponse{},
+		Idempotent: true,
+	})
+	RegisterRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/api/v1/admin/audit",
+		Action: GET_AUDIT_TRAIL_LABEL,
+		Params: []RouteParam{
+			QueryParam("actor_type", "ActorType", STRING_KIND),
+			QueryParam("actor", "Actor", STRING_KIND),
+			QueryParam("action", "AuditAction", STRING_KIND),
+			QueryParam("target", "Target", STRING_KIND),
+			QueryParam("request_uuid", "EventRequestUUID", STRING_KIND),
+			QueryParam("since", "Since", INT_KIND),
+			QueryParam("until", "Until", INT_KIND),
+			QueryParam("before_id", "BeforeID", INT_KIND),
+			QueryParam("limit", "Limit", INT_KIND),
+		},
+	})
+}
+
+// GetAuditTrailRequest 查询审计记录请求，所有条件都可以为空
+type GetAuditTrailRequest struct {
+	BaseRequest
+	ActorType        string `mapstructure:"ActorType" validate:"omitempty,oneof=address api_key admin system"`
+	Actor            string `mapstructure:"Actor" validate:"omitempty,max=64"`
+	AuditAction      string `mapstructure:"AuditAction" validate:"omitempty,max=64"` // 被审计的Action，例如UpdateUserProfile
+	Target           string `mapstructure:"Target" validate:"omitempty,max=64"`
+	EventRequestUUID string `mapstructure:"EventRequestUUID" validate:"omitempty,max=64"` // 产生记录的请求的RequestUUID
+	Since            int64  `mapstructure:"Since" validate:"omitempty,min=0"`             // Unix时间戳（秒），包含
+	Until            int64  `mapstructure:"Until" validate:"omitempty,min=0"`             // Unix时间戳（秒），不包含
+	BeforeID         int64  `mapstructure:"BeforeID" validate:"omitempty,min=0"`          // 上一页响应中的next_before_id
+	Limit            int    `mapstructure:"Limit" validate:"omitempty,min=1,max=500"`
+}
+
+// AuditChangeInfo 一个字段修改前后的值
+type AuditChangeInfo struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// AuditEventInfo 一条审计记录
+type AuditEventInfo struct {
+	ID          int64             `json:"id"`
+	CreatedAt   string            `json:"created_at"`
+	ActorType   string            `json:"actor_type"`
+	Actor       string            `json:"actor"`
+	Action      string            `json:"action"`
+	Target      string            `json:"target"`
+	Changes     []AuditChangeInfo `json:"changes"`
+	Reason      string            `json:"reason,omitempty"`
+	IP          string            `json:"ip,omitempty"`
+	RequestUUID string            `json:"request_uuid,omitempty"`
+}
+
+// GetAuditTrailResponse 查询审计记录响应，按时间从新到旧排列
+type GetAuditTrailResponse struct {
+	BaseResponse
+	Events []AuditEventInfo `json:"events"`
+	// NextBeforeID 还有更早的记录时不为0，作为下一页请求的BeforeID
+	NextBeforeID int64 `json:"next_before_id,omitempty"`
+}
+
+// GetAuditTrailTask 查询审计记录任务
+type GetAuditTrailTask struct {
+	Request  *GetAuditTrailRequest
+	Response *GetAuditTrailResponse
+	store    db.Store
+}
+
+// NewGetAuditTrailRequest 创建查询审计记录请求
+func NewGetAuditTrailRequest(data *map[string]interface{}) (*GetAuditTrailRequest, error) {
+	req := &GetAuditTrailRequest{}
+	err := mapstructure.Decode(*data, &req)
+	if err != nil {
+		return nil, err
+	}
+	req.BaseRequest.RequestUUID = (*data)["RequestUUID"].(string)
+	return req, nil
+}
+
+// NewGetAuditTrailResponse 创建查询审计记录响应
+func NewGetAuditTrailResponse(sessionId string) *GetAuditTrailResponse {
+	return &GetAuditTrailResponse{
+		BaseResponse: BaseResponse{
+			Action:      GET_AUDIT_TRAIL_LABEL + "Response",
+			RequestUUID: sessionId,
+			RetCode:     0,
+		},
+		Events: []AuditEventInfo{},
+	}
+}
+
+// NewGetAuditTrailTask 创建查询审计记录任务
+func NewGetAuditTrailTask(data *map[string]interface{}, deps *Deps) (Task, error) {
+	req, err := NewGetAuditTrailRequest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &GetAuditTrailTask{
+		Request:  req,
+		Response: NewGetAuditTrailResponse(req.BaseRequest.RequestUUID),
+		store:    deps.Store,
+	}
+
+	validate := validator.New()
+	err = validate.Struct(task.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Run 执行查询审计记录任务
+func (task *GetAuditTrailTask) Run(c *gin.Context) (Response, error) {
+	req := task.Request
+	limit := req.Limit
+	if limit == 0 {
+		limit = DEFAULT_AUDIT_TRAIL_LIMIT
+	}
+	filter := db.AuditFilter{
+		ActorType:   req.ActorType,
+		Actor:       req.Actor,
+		Action:      req.AuditAction,
+		Target:      req.Target,
+		RequestUUID: req.EventRequestUUID,
+		BeforeID:    req.BeforeID,
+		// 多查一条判断是否还有下一页
+		Limit: limit + 1,
+	}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until > 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+
+	events, err := task.store.AuditEvents().Find(c.Request.Context(), filter)
+	if err != nil {
+		logger.ErrorContext(c, "查询审计记录失败: %v", err)
+		task.Response.SetRetCode(500)
+		task.Response.SetMessage("Failed to get audit trail")
+		return task.Response, nil
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		task.Response.NextBeforeID = events[limit-1].ID
+	}
+
+	for _, event := range events {
+		changes := make([]AuditChangeInfo, 0, len(event.Changes))
+		for _, change := range event.Changes {
+			changes = append(changes, AuditChangeInfo{Field: change.Field, From: change.From, To: change.To})
+		}
+		task.Response.Events = append(task.Response.Events, AuditEventInfo{
+			ID:          event.ID,
+			CreatedAt:   event.CreatedAt.Format("2006-01-02 15:04:05"),
+			ActorType:   event.ActorType,
+			Actor:       event.Actor,
+			Action:      event.Action,
+			Target:      event.Target,
+			Changes:     changes,
+			Reason:      event.Reason,
+			IP:          event.IP,
+			RequestUUID: event.RequestUUID,
+		})
+	}
+	return task.Response, nil
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"beast-royale-backend/internal/db"
 	"beast-royale-backend/internal/logger"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -11,6 +12,16 @@ import (
 
 func init() {
 	Register(GET_USER_PROFILE_LABEL, NewGetUserProfileTask, COOKIEAUTH)
+	RegisterSchema(GET_USER_PROFILE_LABEL, Schema{
+		Request:    GetUserProfileRequest{},
+		Response:   GetUserProfileResponse{},
+		Idempotent: true,
+	})
+	RegisterRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/api/v1/profile",
+		Action: GET_USER_PROFILE_LABEL,
+	})
 }
 
 // GetUserProfileRequest 获取用户档案请求
@@ -40,6 +51,7 @@ type GetUserProfileResponse struct {
 type GetUserProfileTask struct {
 	Request  *GetUserProfileRequest
 	Response *GetUserProfileResponse
+	store    db.Store
 }
 
 // NewGetUserProfileRequest 创建获取用户档案请求
@@ -65,7 +77,7 @@ func NewGetUserProfileResponse(sessionId string) *GetUserProfileResponse {
 }
 
 // NewGetUserProfileTask 创建获取用户档案任务
-func NewGetUserProfileTask(data *map[string]interface{}) (Task, error) {
+func NewGetUserProfileTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewGetUserProfileRequest(data)
 	if err != nil {
 		return nil, err
@@ -74,6 +86,7 @@ func NewGetUserProfileTask(data *map[string]interface{}) (Task, error) {
 	task := &GetUserProfileTask{
 		Request:  req,
 		Response: NewGetUserProfileResponse(req.BaseRequest.RequestUUID),
+		store:    deps.Store,
 	}
 
 	validate := validator.New()
@@ -104,14 +117,13 @@ func (task *GetUserProfileTask) Run(c *gin.Context) (Response, error) {
 	}
 
 	// 从数据库获取用户档案
-	profile, err := db.GetUserProfileByAddress(address)
+	profile, err := task.store.UserProfiles().GetByAddress(c.Request.Context(), address)
 	if err != nil {
-		logger.Error("获取用户档案失败: %v", err)
+		logger.ErrorContext(c, "获取用户档案失败: %v", err)
 		task.Response.SetRetCode(500)
 		task.Response.SetMessage("Failed to get user profile")
 		return task.Response, nil
 	}
-
 	// 填充响应数据
 	task.Response.Address = profile.Address
 	task.Response.Username = profile.Username
@@ -1,12 +1,26 @@
 package api
 
 import (
+	"net/http"
+
+	"beast-royale-backend/internal/health"
+
 	"github.com/gin-gonic/gin"
 	"github.com/mitchellh/mapstructure"
 )
 
 func init() {
 	Register(HEALTH_CHECK_LABEL, NewHealthCheckTask, NOAUTH)
+	RegisterSchema(HEALTH_CHECK_LABEL, Schema{
+		Request:    HealthCheckRequest{},
+		Response:   HealthCheckResponse{},
+		Idempotent: true,
+	})
+	RegisterRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/api/v1/health",
+		Action: HEALTH_CHECK_LABEL,
+	})
 }
 
 // HealthCheckRequest 健康检查请求
@@ -17,8 +31,10 @@ type HealthCheckRequest struct {
 // HealthCheckResponse 健康检查响应
 type HealthCheckResponse struct {
 	BaseResponse
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status     string                            `json:"status"`
+	Version    string                            `json:"version"`
+	Commit     string                            `json:"commit"`
+	Components map[string]health.ComponentStatus `json:"components,omitempty"`
 }
 
 // HealthCheckTask 健康检查任务
@@ -50,7 +66,7 @@ func NewHealthCheckResponse(sessionId string) *HealthCheckResponse {
 }
 
 // NewHealthCheckTask 创建健康检查任务
-func NewHealthCheckTask(data *map[string]interface{}) (Task, error) {
+func NewHealthCheckTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewHealthCheckRequest(data)
 	if err != nil {
 		return nil, err
@@ -64,10 +80,18 @@ func NewHealthCheckTask(data *map[string]interface{}) (Task, error) {
 	return task, nil
 }
 
-// Run 执行健康检查任务
+// Run 执行健康检查任务，与 /readyz 一样探测数据库和Redis，只返回各依赖的状态，错误信息写入日志
 func (task *HealthCheckTask) Run(c *gin.Context) (Response, error) {
-	task.Response.Status = "healthy"
-	task.Response.Version = "1.0.0"
+	report := health.DefaultChecker.Ready(c)
+	task.Response.Status = report.Status
+	task.Response.Version = report.Version
+	task.Response.Commit = report.Commit
+	task.Response.Components = report.Components
+	if !report.Ready() {
+		task.Response.SetRetCode(SERVICE_UNAVAILABLE_CODE)
+		task.Response.SetMessage("Service is not ready")
+		return task.Response, nil
+	}
 	task.Response.SetMessage("Service is running")
 	return task.Response, nil
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"beast-royale-backend/internal/logger"
+	"net/http"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,16 @@ import (
 
 func init() {
 	Register("Logout", NewLogoutTask, NOAUTH)
+	RegisterSchema("Logout", Schema{
+		Request:    LogoutRequest{},
+		Response:   LogoutResponse{},
+		Idempotent: true,
+	})
+	RegisterRoute(Route{
+		Method: http.MethodPost,
+		Path:   "/api/v1/logout",
+		Action: "Logout",
+	})
 }
 
 // LogoutRequest 退出登录请求
@@ -23,6 +34,7 @@ type LogoutResponse struct {
 }
 
 // LogoutTask 退出登录任务
+// redis和memory存储删除服务端的session；cookie存储只能让浏览器删除cookie，已复制的cookie在session_timeout内仍然有效
 type LogoutTask struct {
 	Request  *LogoutRequest
 	Response *LogoutResponse
@@ -51,7 +63,7 @@ func NewLogoutResponse(sessionId string) *LogoutResponse {
 }
 
 // NewLogoutTask 创建退出登录任务
-func NewLogoutTask(data *map[string]interface{}) (Task, error) {
+func NewLogoutTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewLogoutRequest(data)
 	if err != nil {
 		return nil, err
@@ -75,7 +87,7 @@ func (task *LogoutTask) Run(c *gin.Context) (Response, error) {
 	address := ""
 	if addr != nil {
 		address = addr.(string)
-		logger.Info("用户 %s 正在退出登录", address)
+		logger.InfoContext(c, "用户 %s 正在退出登录", address)
 	}
 
 	// 清除session中的所有数据
@@ -84,13 +96,13 @@ func (task *LogoutTask) Run(c *gin.Context) (Response, error) {
 	// 保存session（这会删除Redis中的session数据）
 	err := session.Save()
 	if err != nil {
-		logger.Error("清除session失败: %v", err)
+		logger.ErrorContext(c, "清除session失败: %v", err)
 		task.Response.SetRetCode(500)
 		task.Response.SetMessage("Failed to logout")
 		return task.Response, nil
 	}
 
-	logger.Info("用户 %s 退出登录成功", address)
+	logger.InfoContext(c, "用户 %s 退出登录成功", address)
 	task.Response.SetMessage("Logout successful")
 	return task.Response, nil
 }
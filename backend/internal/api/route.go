@@ -0,0 +1,71 @@
+package api
+
+// ParamSource REST路由参数的来源
+type ParamSource uint8
+
+const (
+	_            ParamSource = iota
+	PATH_SOURCE              // 路径参数，例如 /users/:address
+	QUERY_SOURCE             // 查询参数
+	BODY_SOURCE              // 请求体字段
+)
+
+// ParamKind REST路由参数的类型，路径和查询参数都是字符串，按类型转换后再交给Action
+type ParamKind uint8
+
+const (
+	STRING_KIND ParamKind = iota
+	INT_KIND
+	BOOL_KIND
+)
+
+// RouteParam 将URL或请求体中的一个参数绑定到Action参数
+type RouteParam struct {
+	Source   ParamSource
+	Name     string // URL或请求体中的参数名
+	Param    string // Action参数名
+	Kind     ParamKind
+	Required bool
+}
+
+// Route 将已注册的Action暴露为REST路由
+//
+// 请求体中的字段原样作为Action参数（与/api一致），Params中声明的参数会覆盖同名字段。
+// 路由与/api共用PreJob、限流和鉴权流程，HTTP状态码由RetCode映射
+type Route struct {
+	Method string
+	Path   string // gin路由路径
+	Action string
+	Params []RouteParam
+	// StatusMap 可选，覆盖默认的RetCode到HTTP状态码的映射
+	StatusMap map[int]int
+}
+
+var _routes []Route
+
+// RegisterRoute 注册REST路由，通常在Action的init中与Register一起调用
+func RegisterRoute(route Route) {
+	_routes = append(_routes, route)
+}
+
+// GetAllRoutes 按注册顺序返回所有REST路由
+func GetAllRoutes() []Route {
+	routes := make([]Route, len(_routes))
+	copy(routes, _routes)
+	return routes
+}
+
+// PathParam 绑定路径参数
+func PathParam(name, param string) RouteParam {
+	return RouteParam{Source: PATH_SOURCE, Name: name, Param: param, Required: true}
+}
+
+// QueryParam 绑定查询参数
+func QueryParam(name, param string, kind ParamKind) RouteParam {
+	return RouteParam{Source: QUERY_SOURCE, Name: name, Param: param, Kind: kind}
+}
+
+// BodyParam 将请求体中的字段重命名为Action参数
+func BodyParam(name, param string, kind ParamKind) RouteParam {
+	return RouteParam{Source: BODY_SOURCE, Name: name, Param: param, Kind: kind}
+}
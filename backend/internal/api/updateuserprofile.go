@@ -1,8 +1,15 @@
 package api
 
 import (
+	"beast-royale-backend/internal/audit"
+	"beast-royale-backend/internal/dao"
 	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/eventbus"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/push"
+	"errors"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +19,20 @@ import (
 
 func init() {
 	Register(UPDATE_USER_PROFILE_LABEL, NewUpdateUserProfileTask, COOKIEAUTH)
+	RegisterSchema(UPDATE_USER_PROFILE_LABEL, Schema{
+		Request:    UpdateUserProfileRequest{},
+		Response:   UpdateUserProfileResponse{},
+		Idempotent: false, // 修改用户名有冷却期，重试可能失败
+		DryRun:     true,
+	})
+	RegisterRoute(Route{
+		Method: http.MethodPatch,
+		Path:   "/api/v1/profile",
+		Action: UPDATE_USER_PROFILE_LABEL,
+		Params: []RouteParam{
+			QueryParam("dry_run", DRY_RUN, BOOL_KIND),
+		},
+	})
 }
 
 // UpdateUserProfileRequest 更新用户档案请求
@@ -19,7 +40,7 @@ type UpdateUserProfileRequest struct {
 	BaseRequest
 	Username        string `mapstructure:"Username" validate:"omitempty,min=3,max=20"`
 	Bio             string `mapstructure:"Bio" validate:"omitempty,max=500"`
-	AvatarURL       string `mapstructure:"AvatarURL" validate:"omitempty,max=50"`
+	AvatarURL       string `mapstructure:"AvatarURL" validate:"omitempty,max=255"`
 	DiscordURL      string `mapstructure:"DiscordURL" validate:"omitempty,max=100"`
 	DiscordUsername string `mapstructure:"DiscordUsername" validate:"omitempty,max=50"`
 	XURL            string `mapstructure:"XURL" validate:"omitempty,max=100"`
@@ -43,12 +64,23 @@ type UpdateUserProfileResponse struct {
 	UpdatedAt          string `json:"updated_at"`
 	LastUsernameUpdate string `json:"last_username_update"`
 	UsernameUpdated    bool   `json:"username_updated"` // 标识用户名是否更新
+	// DryRun时返回将要变化的字段，不会提交任何修改
+	DryRun   bool          `json:"dry_run,omitempty"`
+	Changes  []FieldChange `json:"changes,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"` // 不会生效的修改，例如用户名处于冷却期
 }
 
+// usernameCooldown 两次修改用户名的最小间隔
+const usernameCooldown = 24 * time.Hour
+
+// errUsernameTaken 用户名已被占用，用于回滚事务
+var errUsernameTaken = errors.New("username already taken")
+
 // UpdateUserProfileTask 更新用户档案任务
 type UpdateUserProfileTask struct {
 	Request  *UpdateUserProfileRequest
 	Response *UpdateUserProfileResponse
+	store    db.Store
 }
 
 // NewUpdateUserProfileRequest 创建更新用户档案请求
@@ -74,7 +106,7 @@ func NewUpdateUserProfileResponse(sessionId string) *UpdateUserProfileResponse {
 }
 
 // NewUpdateUserProfileTask 创建更新用户档案任务
-func NewUpdateUserProfileTask(data *map[string]interface{}) (Task, error) {
+func NewUpdateUserProfileTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewUpdateUserProfileRequest(data)
 	if err != nil {
 		return nil, err
@@ -83,6 +115,7 @@ func NewUpdateUserProfileTask(data *map[string]interface{}) (Task, error) {
 	task := &UpdateUserProfileTask{
 		Request:  req,
 		Response: NewUpdateUserProfileResponse(req.BaseRequest.RequestUUID),
+		store:    deps.Store,
 	}
 
 	validate := validator.New()
@@ -96,6 +129,15 @@ func NewUpdateUserProfileTask(data *map[string]interface{}) (Task, error) {
 
 // Run 执行更新用户档案任务
 func (task *UpdateUserProfileTask) Run(c *gin.Context) (Response, error) {
+	return task.run(c, false)
+}
+
+// DryRun 执行校验和业务检查（用户名占用、修改冷却期），报告将要变化的字段，事务回滚不提交
+func (task *UpdateUserProfileTask) DryRun(c *gin.Context) (Response, error) {
+	return task.run(c, true)
+}
+
+func (task *UpdateUserProfileTask) run(c *gin.Context, dryRun bool) (Response, error) {
 	// 从session中获取地址（由AuthMiddleware设置）
 	_params, _ := c.Get("params")
 	params, ok := _params.(*map[string]interface{})
@@ -112,79 +154,98 @@ func (task *UpdateUserProfileTask) Run(c *gin.Context) (Response, error) {
 		return task.Response, nil
 	}
 
-	// 从数据库获取用户档案
-	profile, err := db.GetUserProfileByAddress(address)
-	if err != nil {
-		logger.Error("获取用户档案失败: %v", err)
-		task.Response.SetRetCode(500)
-		task.Response.SetMessage("Failed to get user profile")
-		return task.Response, nil
-	}
-
-	// 检查用户名修改权限
+	task.Response.DryRun = dryRun
+	var profile *dao.UserProfile
 	usernameUpdated := false
 	attemptingUsernameUpdate := false // 后端自动判断是否尝试修改用户名
 
-	// 判断用户是否尝试修改用户名
-	if task.Request.Username != "" && task.Request.Username != profile.Username {
-		attemptingUsernameUpdate = true
-
-		// 检查用户名是否已被其他用户使用
-		existingProfile, err := db.GetUserProfileByUsername(task.Request.Username)
-		if err == nil && existingProfile != nil && existingProfile.Address != address {
-			task.Response.SetRetCode(400)
-			task.Response.SetMessage("Username already taken")
-			return task.Response, nil
+	// 数据库调用使用请求的context而不是gin.Context：驱动会在后台goroutine中监听ctx.Done()，
+	// 而gin.Context在请求结束后会被复用
+	ctx := c.Request.Context()
+	// 检查和保存在同一个事务中执行，DryRun时回滚
+	err := task.store.RunInTx(ctx, dryRun, func(tx db.Store) error {
+		var err error
+		// 从数据库获取用户档案
+		profile, err = tx.UserProfiles().GetByAddress(ctx, address)
+		if err != nil {
+			logger.ErrorContext(c, "获取用户档案失败: %v", err)
+			task.Response.SetRetCode(500)
+			task.Response.SetMessage("Failed to get user profile")
+			return err
 		}
+		before := *profile
 
-		// 检查是否可以修改用户名（24小时内只能修改一次）
-		if profile.LastUsernameUpdate != nil {
-			timeSinceLastUpdate := time.Since(*profile.LastUsernameUpdate)
-			if timeSinceLastUpdate < 24*time.Hour {
+		// 判断用户是否尝试修改用户名
+		if task.Request.Username != "" && task.Request.Username != profile.Username {
+			attemptingUsernameUpdate = true
+
+			// 检查用户名是否已被其他用户使用
+			existingProfile, err := tx.UserProfiles().GetByUsername(ctx, task.Request.Username)
+			if err == nil && existingProfile != nil && existingProfile.Address != address {
+				task.Response.SetRetCode(400)
+				task.Response.SetMessage("Username already taken")
+				return errUsernameTaken
+			}
+
+			// 检查是否可以修改用户名（24小时内只能修改一次）
+			if profile.LastUsernameUpdate != nil && time.Since(*profile.LastUsernameUpdate) < usernameCooldown {
 				// 用户名不能更新，但继续更新其他字段
-				logger.Info("用户名 %s 在24小时内不能更新，跳过用户名字段", address)
+				logger.InfoContext(c, "用户名 %s 在24小时内不能更新，跳过用户名字段", address)
+				task.Response.Warnings = append(task.Response.Warnings, fmt.Sprintf(
+					"Username can be changed again after %s", profile.LastUsernameUpdate.Add(usernameCooldown).Format("2006-01-02 15:04:05")))
 			} else {
-				// 可以更新用户名
+				// 从未更新过或已过冷却期，可以更新用户名
 				profile.Username = task.Request.Username
 				now := time.Now()
 				profile.LastUsernameUpdate = &now
 				usernameUpdated = true
 			}
-		} else {
-			// 从未更新过用户名，可以更新
-			profile.Username = task.Request.Username
-			now := time.Now()
-			profile.LastUsernameUpdate = &now
-			usernameUpdated = true
 		}
-	}
 
-	// 更新其他字段
-	if task.Request.Bio != "" {
-		profile.Bio = task.Request.Bio
-	}
-	if task.Request.AvatarURL != "" {
-		profile.AvatarURL = task.Request.AvatarURL
-	}
-	if task.Request.DiscordURL != "" {
-		profile.DiscordURL = task.Request.DiscordURL
-	}
-	if task.Request.DiscordUsername != "" {
-		profile.DiscordUsername = task.Request.DiscordUsername
-	}
-	if task.Request.XURL != "" {
-		profile.XURL = task.Request.XURL
-	}
-	if task.Request.XUsername != "" {
-		profile.XUsername = task.Request.XUsername
-	}
+		// 更新其他字段
+		if task.Request.Bio != "" {
+			profile.Bio = task.Request.Bio
+		}
+		if task.Request.AvatarURL != "" {
+			profile.AvatarURL = task.Request.AvatarURL
+		}
+		if task.Request.DiscordURL != "" {
+			profile.DiscordURL = task.Request.DiscordURL
+		}
+		if task.Request.DiscordUsername != "" {
+			profile.DiscordUsername = task.Request.DiscordUsername
+		}
+		if task.Request.XURL != "" {
+			profile.XURL = task.Request.XURL
+		}
+		if task.Request.XUsername != "" {
+			profile.XUsername = task.Request.XUsername
+		}
+		if dryRun {
+			task.Response.Changes = profileChanges(&before, profile)
+		}
 
-	// 保存到数据库
-	err = db.UpdateUserProfile(profile)
+		// 保存到数据库，DryRun时同样执行以检查数据库约束（例如用户名唯一索引）
+		if err := tx.UserProfiles().Update(ctx, profile); err != nil {
+			logger.ErrorContext(c, "更新用户档案失败: %v", err)
+			task.Response.SetRetCode(500)
+			task.Response.SetMessage("Failed to update user profile")
+			return err
+		}
+
+		// 审计记录与修改在同一个事务中提交，DryRun时一起回滚
+		if changes := audit.ProfileDiff(&before, profile); len(changes) > 0 {
+			event := newAuditEvent(c, UPDATE_USER_PROFILE_LABEL, address, task.Request.RequestUUID, changes)
+			if err := tx.AuditEvents().Append(ctx, event); err != nil {
+				logger.ErrorContext(c, "写入审计记录失败: %v", err)
+				task.Response.SetRetCode(500)
+				task.Response.SetMessage("Failed to update user profile")
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Error("更新用户档案失败: %v", err)
-		task.Response.SetRetCode(500)
-		task.Response.SetMessage("Failed to update user profile")
 		return task.Response, nil
 	}
 
@@ -212,18 +273,51 @@ func (task *UpdateUserProfileTask) Run(c *gin.Context) (Response, error) {
 	// 设置用户名更新状态
 	task.Response.UsernameUpdated = usernameUpdated
 
+	message := "User profile updated successfully"
+	if dryRun {
+		message = "User profile update validated, nothing was saved"
+	}
+
 	// 根据用户名是否更新设置不同的返回码和消息
 	if usernameUpdated {
 		task.Response.SetRetCode(0) // 完全成功：所有字段都更新成功
-		task.Response.SetMessage("User profile updated successfully")
+		task.Response.SetMessage(message)
 	} else if attemptingUsernameUpdate && !usernameUpdated {
 		// 部分成功：尝试更新用户名但被限制，其他字段更新成功
 		task.Response.SetRetCode(206) // 206 Partial Content - 部分成功
-		task.Response.SetMessage("User profile updated successfully")
+		task.Response.SetMessage(message)
 	} else {
 		// 完全成功：只更新了其他字段，没有尝试更新用户名
 		task.Response.SetRetCode(0) // 完全成功
-		task.Response.SetMessage("User profile updated successfully")
+		task.Response.SetMessage(message)
+	}
+
+	if dryRun {
+		return task.Response, nil
+	}
+
+	// 通知该用户的其他在线连接（例如其他标签页，可能在其他实例上）档案已变更
+	err = eventbus.Publish(c, eventbus.User(address), push.NewEvent(push.USER_PROFILE_UPDATED_EVENT, task.Response))
+	if err != nil {
+		logger.ErrorContext(c, "发布档案变更事件失败: %v", err)
 	}
 	return task.Response, nil
 }
+
+// profileChanges 比较更新前后的用户档案，返回变化的字段（字段名与响应一致）
+func profileChanges(before, after *dao.UserProfile) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	add("username", before.Username, after.Username)
+	add("bio", before.Bio, after.Bio)
+	add("avatar_url", before.AvatarURL, after.AvatarURL)
+	add("discord_url", before.DiscordURL, after.DiscordURL)
+	add("discord_username", before.DiscordUsername, after.DiscordUsername)
+	add("x_url", before.XURL, after.XURL)
+	add("x_username", before.XUsername, after.XUsername)
+	return changes
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAlice = "0x00000000000000000000000000000000000000a1"
+	testBob   = "0x00000000000000000000000000000000000000b2"
+)
+
+// runAction 以已登录的address执行Action，与handle.Dispatch一样通过NewTask注入依赖
+func runAction(t *testing.T, deps *Deps, action, address string, params map[string]interface{}, dryRun bool) Response {
+	t.Helper()
+	data := map[string]interface{}{"Action": action, "RequestUUID": "test-" + action, "Address": address}
+	for k, v := range params {
+		data[k] = v
+	}
+	task, err := NewTask(action, &data, deps)
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api", nil)
+	c.Set("params", &data)
+	c.Set("address", address)
+
+	run := task.Run
+	if dryRun {
+		run = task.(DryRunner).DryRun
+	}
+	response, err := run(c)
+	if err != nil {
+		t.Fatalf("run task: %v", err)
+	}
+	return response
+}
+
+func newTestDeps(t *testing.T) (*Deps, *db.MemoryStore) {
+	t.Helper()
+	store := db.NewMemoryStore()
+	ctx := context.Background()
+	for _, address := range []string{testAlice, testBob} {
+		if _, err := db.EnsureUserProfileExists(ctx, store.UserProfiles(), address); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Deps{Store: store}, store
+}
+
+func TestUpdateUserProfileTask(t *testing.T) {
+	deps, store := newTestDeps(t)
+	ctx := context.Background()
+
+	resp := runAction(t, deps, UPDATE_USER_PROFILE_LABEL, testAlice, map[string]interface{}{"Username": "alice", "Bio": "hello"}, false)
+	if resp.GetRetCode() != 0 {
+		t.Fatalf("RetCode = %d, message: %s", resp.GetRetCode(), resp.GetMessage())
+	}
+	profile, err := store.UserProfiles().GetByAddress(ctx, testAlice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Username != "alice" || profile.Bio != "hello" || profile.LastUsernameUpdate == nil {
+		t.Fatalf("profile not saved: %+v", profile)
+	}
+
+	// 修改与审计记录在同一个事务中提交
+	events, err := store.AuditEvents().Find(ctx, db.AuditFilter{Target: testAlice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ActorType != dao.ACTOR_ADDRESS || events[0].Actor != testAlice || len(events[0].Changes) != 2 {
+		t.Fatalf("unexpected audit events: %+v", events)
+	}
+}
+
+func TestUpdateUserProfileTaskUsernameTaken(t *testing.T) {
+	deps, store := newTestDeps(t)
+	ctx := context.Background()
+	runAction(t, deps, UPDATE_USER_PROFILE_LABEL, testBob, map[string]interface{}{"Username": "taken"}, false)
+
+	resp := runAction(t, deps, UPDATE_USER_PROFILE_LABEL, testAlice, map[string]interface{}{"Username": "taken", "Bio": "not saved"}, false)
+	if resp.GetRetCode() != 400 || resp.GetMessage() != "Username already taken" {
+		t.Fatalf("RetCode = %d, message: %s", resp.GetRetCode(), resp.GetMessage())
+	}
+	// 整个事务回滚，其他字段和审计记录都不保存
+	profile, err := store.UserProfiles().GetByAddress(ctx, testAlice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Bio != "" {
+		t.Fatalf("profile changed after rejected update: %+v", profile)
+	}
+	if events, _ := store.AuditEvents().Find(ctx, db.AuditFilter{Target: testAlice}); len(events) != 0 {
+		t.Fatalf("unexpected audit events: %+v", events)
+	}
+}
+
+func TestUpdateUserProfileTaskDryRun(t *testing.T) {
+	deps, store := newTestDeps(t)
+	ctx := context.Background()
+
+	resp := runAction(t, deps, UPDATE_USER_PROFILE_LABEL, testAlice, map[string]interface{}{"Username": "preview", "Bio": "preview"}, true)
+	if resp.GetRetCode() != 0 {
+		t.Fatalf("RetCode = %d, message: %s", resp.GetRetCode(), resp.GetMessage())
+	}
+	if changes := resp.(*UpdateUserProfileResponse).Changes; len(changes) != 2 {
+		t.Fatalf("changes = %+v, want username and bio", changes)
+	}
+	profile, err := store.UserProfiles().GetByAddress(ctx, testAlice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Username != testAlice || profile.Bio != "" {
+		t.Fatalf("dry run saved changes: %+v", profile)
+	}
+}
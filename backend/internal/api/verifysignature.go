@@ -1,10 +1,12 @@
 package api
 
 import (
+	"beast-royale-backend/internal/audit"
 	"beast-royale-backend/internal/db"
 	"beast-royale-backend/internal/logger"
 	"beast-royale-backend/internal/wallet"
-	"fmt"
+	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/gin-contrib/sessions"
@@ -15,26 +17,42 @@ import (
 
 func init() {
 	Register(VERIFY_SIGNATURE_LABEL, NewVerifySignatureTask, NOAUTH)
+	RegisterSchema(VERIFY_SIGNATURE_LABEL, Schema{
+		Request:    VerifySignatureRequest{},
+		Response:   VerifySignatureResponse{},
+		Idempotent: false, // 验证成功后nonce被删除，不能重试
+	})
+	RegisterRoute(Route{
+		Method: http.MethodPost,
+		Path:   "/api/v1/wallet/verify",
+		Action: VERIFY_SIGNATURE_LABEL,
+		Params: []RouteParam{
+			BodyParam("address", ADDRESS, STRING_KIND),
+			BodyParam("signature", "Signature", STRING_KIND),
+			BodyParam("nonce", "Nonce", INT_KIND),
+		},
+	})
 }
 
 // VerifySignatureRequest 验证签名请求
 type VerifySignatureRequest struct {
 	BaseRequest
 	Address   string `mapstructure:"Address" validate:"required"`
-	Signature string `mapstructure:"Signature" validate:"required"`
+	Signature string `mapstructure:"Signature" validate:"required" log:"sensitive"`
 	Nonce     int    `mapstructure:"Nonce" validate:"required"`
 }
 
 // VerifySignatureResponse 验证签名响应
 type VerifySignatureResponse struct {
 	BaseResponse
-	Token string `json:"token"`
+	Token string `json:"token" log:"sensitive"`
 }
 
 // VerifySignatureTask 验证签名任务
 type VerifySignatureTask struct {
 	Request  *VerifySignatureRequest
 	Response *VerifySignatureResponse
+	store    db.Store
 }
 
 // NewVerifySignatureRequest 创建验证签名请求
@@ -60,7 +78,7 @@ func NewVerifySignatureResponse(sessionId string) *VerifySignatureResponse {
 }
 
 // NewVerifySignatureTask 创建验证签名任务
-func NewVerifySignatureTask(data *map[string]interface{}) (Task, error) {
+func NewVerifySignatureTask(data *map[string]interface{}, deps *Deps) (Task, error) {
 	req, err := NewVerifySignatureRequest(data)
 	if err != nil {
 		return nil, err
@@ -69,6 +87,7 @@ func NewVerifySignatureTask(data *map[string]interface{}) (Task, error) {
 	task := &VerifySignatureTask{
 		Request:  req,
 		Response: NewVerifySignatureResponse(req.BaseRequest.RequestUUID),
+		store:    deps.Store,
 	}
 
 	validate := validator.New()
@@ -96,7 +115,7 @@ func (task *VerifySignatureTask) Run(c *gin.Context) (Response, error) {
 	key := MakeAddrNonceKey(task.Request.Address)
 	v := session.Get(key)
 	if v == nil {
-		logger.Error("用户 %s 的nonce不存在或已过期", task.Request.Address)
+		logger.ErrorContext(c, "用户 %s 的nonce不存在或已过期", task.Request.Address)
 		task.Response.SetRetCode(401)
 		task.Response.SetMessage("Nonce not found or expired")
 		return task.Response, nil
@@ -104,7 +123,7 @@ func (task *VerifySignatureTask) Run(c *gin.Context) (Response, error) {
 
 	storedNonce := v.(int)
 	if storedNonce != task.Request.Nonce {
-		logger.Error("用户 %s 的nonce不匹配: 期望 %d, 实际 %d", task.Request.Address, storedNonce, task.Request.Nonce)
+		logger.ErrorContext(c, "用户 %s 的nonce不匹配: 期望 %d, 实际 %d", task.Request.Address, storedNonce, task.Request.Nonce)
 		task.Response.SetRetCode(401)
 		task.Response.SetMessage("Invalid nonce")
 		return task.Response, nil
@@ -116,7 +135,7 @@ func (task *VerifySignatureTask) Run(c *gin.Context) (Response, error) {
 	// 验证签名
 	valid, err := verifySignature(message, task.Request.Signature, task.Request.Address)
 	if err != nil {
-		logger.Error("验证签名失败: %v", err)
+		logger.ErrorContext(c, "验证签名失败: %v", err)
 		task.Response.SetRetCode(401)
 		task.Response.SetMessage("Signature verification failed")
 		return task.Response, nil
@@ -132,24 +151,48 @@ func (task *VerifySignatureTask) Run(c *gin.Context) (Response, error) {
 	session.Delete(key)
 	session.Save()
 
+	// 被封禁的账户不能登录，档案不存在（首次登录）时继续，查询失败时不能放行
+	err = CheckAccount(c.Request.Context(), task.store.UserProfiles(), lowerAddress)
+	if errors.Is(err, ErrAccountSuspended) {
+		logger.WarnContext(c, "已封禁的用户 %s 尝试登录", lowerAddress)
+		task.Response.SetRetCode(403)
+		task.Response.SetMessage("Account suspended")
+		return task.Response, nil
+	}
+	if err != nil {
+		logger.ErrorContext(c, "检查账户状态失败: %v", err)
+		task.Response.SetRetCode(500)
+		task.Response.SetMessage("Failed to check account status")
+		return task.Response, nil
+	}
+
 	// 设置Redis session用于后续认证
 	// 使用gin-sessions的标准方式，将小写地址存储在session中
-	logger.Info("准备保存session: 地址=%s", lowerAddress)
+	c.Set("address", lowerAddress)
+	logger.DebugContext(c, "准备保存session")
 	session.Set("address", lowerAddress)
 	err = session.Save()
 	if err != nil {
-		logger.Error("保存session失败: %v", err)
+		logger.ErrorContext(c, "保存session失败: %v", err)
 	} else {
-		logger.Info("保存session成功: 地址=%s", lowerAddress)
+		logger.InfoContext(c, "保存session成功")
 	}
 
-	// 登录成功后，确保用户档案存在（使用小写地址）
-	err = db.EnsureUserProfileExists(lowerAddress)
+	// 登录成功后，确保用户档案存在（使用小写地址），首次登录创建档案时写入审计记录
+	ctx := c.Request.Context()
+	err = task.store.RunInTx(ctx, false, func(tx db.Store) error {
+		created, err := db.EnsureUserProfileExists(ctx, tx.UserProfiles(), lowerAddress)
+		if err != nil || created == nil {
+			return err
+		}
+		return tx.AuditEvents().Append(ctx, newAuditEvent(c, VERIFY_SIGNATURE_LABEL, lowerAddress,
+			task.Request.RequestUUID, audit.ProfileDiff(nil, created)))
+	})
 	if err != nil {
-		logger.Error("确保用户档案存在失败: %v", err)
+		logger.ErrorContext(c, "确保用户档案存在失败: %v", err)
 		// 不返回错误，因为登录已经成功，档案创建失败不应该影响登录流程
 	} else {
-		logger.Info("用户档案创建/确认成功: %s", lowerAddress)
+		logger.InfoContext(c, "用户档案创建/确认成功: %s", lowerAddress)
 	}
 
 	// 生成token（使用小写地址）
@@ -161,8 +204,8 @@ func (task *VerifySignatureTask) Run(c *gin.Context) (Response, error) {
 
 // constructSignMessage 构造签名消息
 func constructSignMessage(address string, nonce int) string {
-	// 使用与前端一致的签名模板，使用实际的换行符
-	return fmt.Sprintf("连接Beast Royale游戏\n\n点击签名以验证您的身份。\n\nNonce: %d", nonce)
+	// 使用与前端一致的签名模板
+	return wallet.LoginMessage(nonce)
 }
 
 // verifySignature 验证以太坊签名
@@ -0,0 +1,58 @@
+// Package audit 审计记录的公共部分：用户档案的字段比较和过期记录的清理
+//
+// 审计记录由修改数据的一方在同一个事务中通过 db.Store.AuditEvents 写入，
+// 事务回滚（包括DryRun）时审计记录一起回滚。
+package audit
+
+import (
+	"strconv"
+	"time"
+
+	"beast-royale-backend/internal/dao"
+)
+
+// ProfileDiff 比较修改前后的用户档案，返回变化的字段
+// before为nil表示新建，after为nil表示删除，此时列出另一侧所有非空的字段
+func ProfileDiff(before, after *dao.UserProfile) dao.AuditChanges {
+	var changes dao.AuditChanges
+	var from, to []string
+	if before != nil {
+		from = profileFields(before)
+	}
+	if after != nil {
+		to = profileFields(after)
+	}
+	for i, field := range profileFieldNames {
+		var f, t string
+		if from != nil {
+			f = from[i]
+		}
+		if to != nil {
+			t = to[i]
+		}
+		if f != t {
+			changes = append(changes, dao.AuditChange{Field: field, From: f, To: t})
+		}
+	}
+	return changes
+}
+
+// profileFieldNames 参与比较的字段，名称与接口响应一致；时间戳由数据库维护，不记录
+var profileFieldNames = []string{
+	"username", "bio", "avatar_url", "discord_url", "discord_username", "x_url", "x_username",
+	"points", "tokens", "suspended_at",
+}
+
+func profileFields(p *dao.UserProfile) []string {
+	return []string{
+		p.Username, p.Bio, p.AvatarURL, p.DiscordURL, p.DiscordUsername, p.XURL, p.XUsername,
+		strconv.FormatInt(p.Points, 10), strconv.FormatInt(p.Tokens, 10), formatTime(p.SuspendedAt),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/logger"
+)
+
+// PRUNE_BATCH_SIZE 每次删除的最大条数
+const PRUNE_BATCH_SIZE = 1000
+
+// Pruner 按保留期定期删除过期的审计记录，多个实例同时清理不会冲突
+type Pruner struct {
+	events    db.AuditRepository
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewPruner 创建Pruner，retention_days为-1时返回nil
+func NewPruner(events db.AuditRepository, cfg *config.AuditConfig) *Pruner {
+	if cfg.RetentionDays < 0 {
+		return nil
+	}
+	return &Pruner{
+		events:    events,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		interval:  time.Duration(cfg.PruneInterval) * time.Second,
+		now:       time.Now,
+	}
+}
+
+// WithClock 替换时钟，用于测试
+func (p *Pruner) WithClock(now func() time.Time) *Pruner {
+	p.now = now
+	return p
+}
+
+// Prune 删除所有超过保留期的记录，返回删除的条数
+func (p *Pruner) Prune(ctx context.Context) (int64, error) {
+	cutoff := p.now().Add(-p.retention)
+	var total int64
+	for {
+		n, err := p.events.DeleteBefore(ctx, cutoff, PRUNE_BATCH_SIZE)
+		total += n
+		if err != nil || n < PRUNE_BATCH_SIZE {
+			return total, err
+		}
+	}
+}
+
+// Run 立即清理一次，之后按间隔清理，直到ctx取消
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.Prune(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("清理过期审计记录失败: %v", err)
+		} else if n > 0 {
+			logger.Info("已清理过期审计记录 - Count: %d, Retention: %v", n, p.retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"beast-royale-backend/internal/api"
+)
+
+var (
+	baseRequestType  = reflect.TypeOf(api.BaseRequest{})
+	baseResponseType = reflect.TypeOf(api.BaseResponse{})
+)
+
+// generator 根据api包中注册的Schema生成client包的类型和方法
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]struct{}
+	// types 已生成的结构体，Action请求/响应中嵌套的结构体也会一并生成
+	types   map[string]struct{}
+	pending []pendingType
+}
+
+type pendingType struct {
+	t      reflect.Type
+	tagKey string
+}
+
+// Generate 生成client包中每个Action对应的类型和方法，输出已格式化的Go代码
+func Generate(w io.Writer) error {
+	g := &generator{
+		imports: make(map[string]struct{}),
+		types:   make(map[string]struct{}),
+	}
+
+	actions := api.GetAllAction()
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		schema, ok := api.GetSchema(action)
+		if !ok {
+			continue
+		}
+		if err := g.action(action, schema); err != nil {
+			return fmt.Errorf("generate %s: %w", action, err)
+		}
+	}
+	for len(g.pending) > 0 {
+		p := g.pending[0]
+		g.pending = g.pending[1:]
+		if err := g.structType(p.t.Name(), p.t, p.tagKey); err != nil {
+			return fmt.Errorf("generate %s: %w", p.t.Name(), err)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by \"beast-royale-backend gen-client\". DO NOT EDIT.\n\n")
+	out.WriteString("package client\n\n")
+	out.WriteString("import (\n\t\"context\"\n")
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func (g *generator) action(action string, schema api.Schema) error {
+	reqType := indirect(reflect.TypeOf(schema.Request))
+	respType := indirect(reflect.TypeOf(schema.Response))
+	reqName := action + "Request"
+	respName := action + "Response"
+
+	var extra []string
+	if schema.DryRun {
+		extra = append(extra, "\t// DryRun 只做校验和业务检查，不提交任何修改\n\tDryRun bool `json:\"DryRun,omitempty\"`\n")
+	}
+	if err := g.structType(reqName, reqType, "mapstructure", extra...); err != nil {
+		return err
+	}
+	if err := g.structType(respName, respType, "json"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(&g.buf, "\n// %s 调用%s", action, action)
+	if schema.Idempotent {
+		g.buf.WriteString("，幂等，失败时按重试策略自动重试")
+	}
+	g.buf.WriteString("\n")
+	fmt.Fprintf(&g.buf, "func (c *Client) %s(ctx context.Context, req *%s) (*%s, error) {\n", action, reqName, respName)
+	fmt.Fprintf(&g.buf, "\tif req == nil {\n\t\treq = &%s{}\n\t}\n", reqName)
+	fmt.Fprintf(&g.buf, "\tresp := &%s{}\n", respName)
+	fmt.Fprintf(&g.buf, "\tif err := c.Call(ctx, %q, req, resp, %t); err != nil {\n\t\treturn nil, err\n\t}\n", action, schema.Idempotent)
+	g.buf.WriteString("\treturn resp, nil\n}\n")
+	return nil
+}
+
+// structType 生成结构体，tagKey为原类型中字段名所在的标签（请求为mapstructure，响应为json），extra为追加的字段
+func (g *generator) structType(name string, t reflect.Type, tagKey string, extra ...string) error {
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("%s must be a struct, got %s", name, t.Kind())
+	}
+	if _, ok := g.types[name]; ok {
+		return nil
+	}
+	g.types[name] = struct{}{}
+
+	var fields bytes.Buffer
+	if err := g.fields(&fields, t, tagKey); err != nil {
+		return err
+	}
+	for _, field := range extra {
+		fields.WriteString(field)
+	}
+	fmt.Fprintf(&g.buf, "\n// %s 由 %s.%s 生成\n", name, t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:], t.Name())
+	fmt.Fprintf(&g.buf, "type %s struct {\n%s}\n", name, fields.String())
+	return nil
+}
+
+func (g *generator) fields(w *bytes.Buffer, t reflect.Type, tagKey string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			switch f.Type {
+			case baseRequestType:
+				// Action和RequestUUID由Client.Call填充
+				continue
+			case baseResponseType:
+				w.WriteString("\tBaseResponse\n")
+				continue
+			}
+			if indirect(f.Type).Kind() == reflect.Struct {
+				if err := g.fields(w, indirect(f.Type), tagKey); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, squash, skip := fieldName(f, tagKey)
+		if skip {
+			continue
+		}
+		if squash && indirect(f.Type).Kind() == reflect.Struct {
+			if err := g.fields(w, indirect(f.Type), tagKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		typeName, err := g.typeName(f.Type, tagKey)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		jsonTag := name
+		if tagKey == "mapstructure" && !strings.Contains(f.Tag.Get("validate"), "required") {
+			jsonTag += ",omitempty"
+		} else if tagKey == "json" {
+			jsonTag = f.Tag.Get("json")
+		}
+		fmt.Fprintf(w, "\t%s %s `json:%q`\n", f.Name, typeName, jsonTag)
+	}
+	return nil
+}
+
+// fieldName 解析字段在请求/响应中的名称
+func fieldName(f reflect.StructField, tagKey string) (name string, squash bool, skip bool) {
+	tag := f.Tag.Get(tagKey)
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "squash" {
+			squash = true
+		}
+	}
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name, squash, false
+}
+
+// typeName 生成字段类型，外部包的具名类型需要import，模块内部包的结构体会一并生成
+func (g *generator) typeName(t reflect.Type, tagKey string) (string, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeName(t.Elem(), tagKey)
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeName(t.Elem(), tagKey)
+		return "[]" + elem, err
+	case reflect.Map:
+		key, err := g.typeName(t.Key(), tagKey)
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeName(t.Elem(), tagKey)
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	case reflect.Struct:
+		// 模块内部包（api、health等）的结构体无法被外部import，一并生成到client包
+		if isInternalPkg(t.PkgPath()) && t.Name() != "" {
+			if _, ok := g.types[t.Name()]; !ok {
+				g.pending = append(g.pending, pendingType{t: t, tagKey: tagKey})
+			}
+			return t.Name(), nil
+		}
+	}
+
+	if t.PkgPath() != "" {
+		if strings.Contains(t.PkgPath(), "internal") {
+			return "", fmt.Errorf("type %s cannot be used outside the module", t)
+		}
+		g.imports[t.PkgPath()] = struct{}{}
+		return t.String(), nil
+	}
+	if t.Name() == "" {
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+	return t.Name(), nil
+}
+
+// isInternalPkg 是否为本模块internal目录下的包
+func isInternalPkg(pkgPath string) bool {
+	module, _, _ := strings.Cut(baseResponseType.PkgPath(), "/internal/")
+	return strings.HasPrefix(pkgPath, module+"/internal/")
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,147 @@
+package codec
+
+import (
+	"encoding/json"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	ugorji "github.com/ugorji/go/codec"
+)
+
+// Codec /api请求和响应的序列化格式，三种格式使用相同的字段名（json标签）
+type Codec interface {
+	ContentType() string
+	Decode(data []byte, v interface{}) error
+	Encode(v interface{}) ([]byte, error)
+}
+
+var (
+	JSON    Codec = jsonCodec{}
+	MsgPack Codec = newUgorjiCodec("application/msgpack", newMsgpackHandle())
+	CBOR    Codec = newUgorjiCodec("application/cbor", newCborHandle())
+)
+
+// contentTypes 支持的MIME类型，MessagePack没有正式注册的类型，兼容常见写法
+var contentTypes = map[string]Codec{
+	"application/json":        JSON,
+	"application/msgpack":     MsgPack,
+	"application/x-msgpack":   MsgPack,
+	"application/vnd.msgpack": MsgPack,
+	"application/cbor":        CBOR,
+}
+
+// ForContentType 按请求的Content-Type选择编解码器，未携带Content-Type时按JSON处理
+func ForContentType(contentType string) (Codec, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return JSON, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	c, ok := contentTypes[mediaType]
+	return c, ok
+}
+
+// Negotiate 按Accept头选择响应格式，没有可接受的格式时使用fallback（通常是请求的格式）
+func Negotiate(accept string, fallback Codec) Codec {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if c, ok := contentTypes[mediaType]; ok {
+			return c
+		}
+	}
+	return fallback
+}
+
+// Render 使用PreJobMiddleware协商出的格式输出响应，未协商时输出JSON
+func Render(c *gin.Context, status int, obj interface{}) {
+	responseCodec := JSON
+	if v, ok := c.Get("codec"); ok {
+		if negotiated, ok := v.(Codec); ok {
+			responseCodec = negotiated
+		}
+	}
+
+	if responseCodec == JSON {
+		c.JSON(status, obj)
+		return
+	}
+
+	data, err := responseCodec.Encode(obj)
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(status, obj)
+		return
+	}
+	c.Data(status, responseCodec.ContentType(), data)
+}
+
+// AbortWithStatus 中断请求并输出响应
+func AbortWithStatus(c *gin.Context, status int, obj interface{}) {
+	c.Abort()
+	Render(c, status, obj)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type ugorjiCodec struct {
+	contentType string
+	handle      ugorji.Handle
+}
+
+func newUgorjiCodec(contentType string, handle ugorji.Handle) *ugorjiCodec {
+	return &ugorjiCodec{
+		contentType: contentType,
+		handle:      handle,
+	}
+}
+
+func (u *ugorjiCodec) ContentType() string {
+	return u.contentType
+}
+
+func (u *ugorjiCodec) Decode(data []byte, v interface{}) error {
+	return ugorji.NewDecoderBytes(data, u.handle).Decode(v)
+}
+
+func (u *ugorjiCodec) Encode(v interface{}) ([]byte, error) {
+	var out []byte
+	err := ugorji.NewEncoderBytes(&out, u.handle).Encode(v)
+	return out, err
+}
+
+var mapType = reflect.TypeOf(map[string]interface{}(nil))
+
+func newMsgpackHandle() *ugorji.MsgpackHandle {
+	h := &ugorji.MsgpackHandle{}
+	h.MapType = mapType
+	h.WriteExt = true      // 使用新规范，区分str和bin
+	h.RawToString = true   // 解码到interface{}时字符串为string而不是[]byte
+	h.SignedInteger = true // 整数统一解码为int64
+	return h
+}
+
+func newCborHandle() *ugorji.CborHandle {
+	h := &ugorji.CborHandle{}
+	h.MapType = mapType
+	h.SignedInteger = true
+	return h
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"fmt"
+	"testing"
+
+	"beast-royale-backend/internal/api"
+)
+
+// benchInventoryItem 模拟背包/战斗状态中的单个条目
+type benchInventoryItem struct {
+	ID       int64   `json:"id"`
+	Kind     string  `json:"kind"`
+	Level    int     `json:"level"`
+	HP       int     `json:"hp"`
+	Attack   float64 `json:"attack"`
+	Equipped bool    `json:"equipped"`
+}
+
+type benchInventoryResponse struct {
+	api.BaseResponse
+	Address string               `json:"address"`
+	Items   []benchInventoryItem `json:"items"`
+}
+
+func newBenchPayload(n int) *benchInventoryResponse {
+	resp := &benchInventoryResponse{
+		BaseResponse: api.BaseResponse{
+			Action:      "GetInventoryResponse",
+			RequestUUID: "7d9f6c1e-3b0a-4c8e-9a51-2f4d6b8e0c13",
+			Message:     "ok",
+		},
+		Address: "0x52908400098527886e0f7030069857d2e4169ee7",
+	}
+	for i := 0; i < n; i++ {
+		resp.Items = append(resp.Items, benchInventoryItem{
+			ID:       int64(100000 + i),
+			Kind:     fmt.Sprintf("beast-%d", i%17),
+			Level:    i % 60,
+			HP:       1000 + i,
+			Attack:   float64(i) * 1.25,
+			Equipped: i%5 == 0,
+		})
+	}
+	return resp
+}
+
+// BenchmarkEncode 比较三种格式编码同一份响应的耗时和体积（bytes/payload）
+func BenchmarkEncode(b *testing.B) {
+	payload := newBenchPayload(500)
+	for _, c := range []Codec{JSON, MsgPack, CBOR} {
+		b.Run(c.ContentType(), func(b *testing.B) {
+			data, err := c.Encode(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Encode(payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes/payload")
+		})
+	}
+}
+
+// BenchmarkDecode 比较三种格式解码为请求参数map（PreJobMiddleware的用法）的耗时
+func BenchmarkDecode(b *testing.B) {
+	payload := newBenchPayload(500)
+	for _, c := range []Codec{JSON, MsgPack, CBOR} {
+		b.Run(c.ContentType(), func(b *testing.B) {
+			data, err := c.Encode(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var out map[string]interface{}
+				if err := c.Decode(data, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes/payload")
+		})
+	}
+}
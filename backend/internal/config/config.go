@@ -7,38 +7,51 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var GConf *Config
-
-func InitConfig(configPath string) error {
-	cfg, err := LoadConfig(configPath)
+// InitConfig 加载并校验配置，设置为Default的当前配置，之后可以通过Default.Reload重新加载
+func InitConfig(configPath string, overrides ...string) error {
+	cfg, err := LoadConfig(configPath, overrides...)
 	if err != nil {
 		return err
 	}
-	GConf = cfg
+	Default.set(cfg, configPath, overrides)
 	return nil
 }
 
 // Config 应用配置结构
+// 带有 `secret:"true"` tag的字段支持 <key>_file 读取，在 config check 等输出中会被隐藏
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Redis    RedisConfig    `yaml:"redis"`
-	Database DatabaseConfig `yaml:"database"`
-	Logging  LoggingConfig  `yaml:"logging"`
-	Security SecurityConfig `yaml:"security"`
-	CORS     CORSConfig     `yaml:"cors"`
+	Server    ServerConfig    `yaml:"server"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	Security  SecurityConfig  `yaml:"security"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	EventBus  EventBusConfig  `yaml:"event_bus"`
+	Recording RecordingConfig `yaml:"recording"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Audit     AuditConfig     `yaml:"audit"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `yaml:"port"`
-	Host string `yaml:"host"`
+	Port            int    `yaml:"port"`
+	Host            string `yaml:"host"`
+	ShutdownTimeout int    `yaml:"shutdown_timeout"` // 关闭时等待进行中请求完成的最长时间（秒）
+	DrainDelay      int    `yaml:"drain_delay"`      // 标记未就绪后继续接收请求的时间，等待负载均衡摘除流量（秒）
+	// TrustedProxies 反向代理的IP或CIDR，只采用这些代理传入的X-Forwarded-For，为空时客户端IP就是连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// RPCMaxBatchSize /rpc 一次批量请求最多包含的调用数，超过时整个批量被拒绝
+	RPCMaxBatchSize int `yaml:"rpc_max_batch_size"`
 }
 
 // RedisConfig Redis配置
 type RedisConfig struct {
 	Host         string `yaml:"host"`
 	Port         int    `yaml:"port"`
-	Password     string `yaml:"password"`
+	Password     string `yaml:"password" secret:"true"`
 	DB           int    `yaml:"db"`
 	PoolSize     int    `yaml:"pool_size"`
 	MinIdleConns int    `yaml:"min_idle_conns"`
@@ -46,10 +59,12 @@ type RedisConfig struct {
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver    string `yaml:"driver"` // mysql或sqlite，sqlite不需要数据库服务，用于测试和单机开发
+	Path      string `yaml:"path"`   // sqlite数据库文件，:memory: 使用内存数据库，进程退出后数据丢失
 	Host      string `yaml:"host"`
 	Port      int    `yaml:"port"`
 	User      string `yaml:"user"`
-	Password  string `yaml:"password"`
+	Password  string `yaml:"password" secret:"true"`
 	DBName    string `yaml:"dbname"`
 	Charset   string `yaml:"charset"`
 	ParseTime bool   `yaml:"parse_time"`
@@ -58,22 +73,39 @@ type DatabaseConfig struct {
 
 // LoggingConfig 日志配置
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Format string `yaml:"format"`
-	Output string `yaml:"output"`
+	Level        string `yaml:"level"`         // debug、info、warn、error
+	Format       string `yaml:"format"`        // text、json
+	Output       string `yaml:"output"`        // stdout、file、both
+	Dir          string `yaml:"dir"`           // 日志文件目录，output为file或both时使用
+	MaxAge       int    `yaml:"max_age"`       // 日志文件保留天数
+	RotationTime int    `yaml:"rotation_time"` // 日志切割间隔（小时）
+	// MaxFieldSize 请求和响应日志中单个字符串字段的最大长度，MaxBodySize 整条内容的最大长度
+	MaxFieldSize int `yaml:"max_field_size"`
+	MaxBodySize  int `yaml:"max_body_size"`
+	// Trace 开启的跟踪日志类别（例如 signature），不受level限制，只用于排查问题
+	Trace []string `yaml:"trace"`
 }
 
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	SessionSecret  string `yaml:"session_secret"`
+	SessionSecret  string `yaml:"session_secret" secret:"true"` // 单一密钥，配置了session_keyring时只用于验证旧cookie
+	SessionKeyring string `yaml:"session_keyring"`              // session密钥环文件，由 session-keys 命令生成
+	SessionStore   string `yaml:"session_store"`                // session存储：redis、memory或cookie
 	SessionTimeout int    `yaml:"session_timeout"`
 	SessionName    string `yaml:"session_name"`
 	CookieName     string `yaml:"cookie_name"`
-	JWTSecret      string `yaml:"jwt_secret"`
+	JWTSecret      string `yaml:"jwt_secret" secret:"true"`
 	JWTExpiry      int    `yaml:"jwt_expiry"`
+	CookieSecure   bool   `yaml:"cookie_secure"`    // 只通过HTTPS发送session cookie，生产环境应开启
+	CookieSameSite string `yaml:"cookie_same_site"` // lax、strict或none，none要求cookie_secure
+	CookieDomain   string `yaml:"cookie_domain"`    // cookie的Domain，为空时只对当前域名有效
+	// AdminAPIKeys 管理接口的密钥，名称 → 密钥的SHA-256（hex），由 admin key generate 生成
+	// 请求头 X-Admin-Key 携带密钥，审计记录中使用名称
+	AdminAPIKeys map[string]string `yaml:"admin_api_keys"`
 }
 
 // CORSConfig 跨域配置
+// allowed_origins支持通配子域名，例如 https://*.example.com；为空时不允许跨域
 type CORSConfig struct {
 	AllowedOrigins   []string `yaml:"allowed_origins"`
 	AllowedMethods   []string `yaml:"allowed_methods"`
@@ -81,62 +113,206 @@ type CORSConfig struct {
 	AllowCredentials bool     `yaml:"allow_credentials"`
 }
 
-// LoadConfig 从文件加载配置
-func LoadConfig(configPath string) (*Config, error) {
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
-	}
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enabled   bool                     `yaml:"enabled"`
+	Backend   string                   `yaml:"backend"`    // redis（多实例共享）或 memory（单实例）
+	KeyPrefix string                   `yaml:"key_prefix"` // Redis key前缀
+	Default   RateLimitRule            `yaml:"default"`    // 未单独配置的Action使用的默认规则
+	Actions   map[string]RateLimitRule `yaml:"actions"`    // 按Action覆盖的规则
+}
 
-	// 解析YAML
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+// RateLimitRule 针对不同身份维度的限流规则，未配置的维度不限流
+type RateLimitRule struct {
+	IP      *LimitSpec `yaml:"ip"`
+	Address *LimitSpec `yaml:"address"`
+}
+
+// LimitSpec 滑动窗口内允许的最大请求数
+type LimitSpec struct {
+	Limit  int `yaml:"limit"`
+	Window int `yaml:"window"` // 窗口长度（秒）
+}
+
+// GetRule 获取某个Action的限流规则，Action未配置时使用默认规则
+func (c *RateLimitConfig) GetRule(action string) RateLimitRule {
+	if rule, ok := c.Actions[action]; ok {
+		return rule
 	}
+	return c.Default
+}
 
-	// 设置默认值
-	setDefaults(&config)
+// MetricsConfig Prometheus指标配置
+// allow_ips和bearer_token满足其一即可访问，两者都未配置时只允许本机访问
+type MetricsConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Path        string   `yaml:"path"`
+	AllowIPs    []string `yaml:"allow_ips"`                  // 允许访问的IP或CIDR
+	BearerToken string   `yaml:"bearer_token" secret:"true"` // Authorization: Bearer <token>
+}
 
-	return &config, nil
+// TracingConfig OpenTelemetry链路追踪配置
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Exporter    string  `yaml:"exporter"`     // otlp（OTLP/HTTP）或 stdout（本地调试）
+	Endpoint    string  `yaml:"endpoint"`     // OTLP接收地址，例如 localhost:4318
+	Insecure    bool    `yaml:"insecure"`     // OTLP使用http而不是https
+	ServiceName string  `yaml:"service_name"` // 上报的服务名
+	SampleRatio float64 `yaml:"sample_ratio"` // 采样比例（0-1），请求带有已采样的traceparent时始终采样
 }
 
-// setDefaults 设置默认配置值
-func setDefaults(config *Config) {
-	// 服务器默认配置
-	if config.Server.Port == 0 {
-		config.Server.Port = 8080
-	}
-	if config.Server.Host == "" {
-		config.Server.Host = "0.0.0.0"
-	}
+// WebSocketConfig WebSocket长连接配置
+type WebSocketConfig struct {
+	PingInterval   int   `yaml:"ping_interval"`    // 心跳间隔（秒）
+	PongTimeout    int   `yaml:"pong_timeout"`     // 超过该时间未收到pong则断开（秒）
+	WriteTimeout   int   `yaml:"write_timeout"`    // 单次写超时（秒）
+	MaxMessageSize int64 `yaml:"max_message_size"` // 客户端单条消息最大字节数
+	SendQueueSize  int   `yaml:"send_queue_size"`  // 每个连接的发送队列长度，写满即断开
+	// MaxSubscriptions 每个连接最多订阅的主题数
+	MaxSubscriptions int `yaml:"max_subscriptions"`
+}
 
-	// Redis默认配置
-	if config.Redis.Host == "" {
-		config.Redis.Host = "localhost"
-	}
-	if config.Redis.Port == 0 {
-		config.Redis.Port = 6379
+// EventBusConfig 实时事件总线配置
+type EventBusConfig struct {
+	Backend     string `yaml:"backend"`      // memory（单实例）或 redis（多实例通过pub/sub分发）
+	NodeID      string `yaml:"node_id"`      // 实例ID，为空时使用 hostname-pid
+	Channel     string `yaml:"channel"`      // Redis频道及在线状态key的前缀
+	PresenceTTL int    `yaml:"presence_ttl"` // 在线状态TTL（秒），实例按TTL的1/3周期续期
+}
+
+// RecordingConfig 请求录制配置，录制的请求/响应作为mock命令的fixture，仅用于开发环境
+type RecordingConfig struct {
+	Enabled  bool                `yaml:"enabled"`
+	Dir      string              `yaml:"dir"`      // fixture保存目录
+	Scenario string              `yaml:"scenario"` // 录制的fixture所属的场景，为空表示默认场景
+	Actions  []string            `yaml:"actions"`  // 只录制这些Action，为空时录制全部
+	Match    map[string][]string `yaml:"match"`    // 按Action配置mock匹配时需要比较的参数
+}
+
+// AuditConfig 审计记录配置
+type AuditConfig struct {
+	RetentionDays int `yaml:"retention_days"` // 审计记录保留天数，-1表示永久保留
+	PruneInterval int `yaml:"prune_interval"` // 清理过期记录的间隔（秒）
+}
+
+// LoadConfig 加载配置并校验，配置按以下顺序逐层覆盖：
+//  1. 代码中的默认值
+//  2. 配置文件（configPath为空时跳过）
+//  3. BEAST_ 前缀的环境变量，例如 BEAST_DATABASE_PASSWORD
+//  4. 命令行 --set 传入的 key=value，例如 server.port=9090
+//
+// 敏感字段还可以通过 <key>_file 或 BEAST_<KEY>_FILE 从文件读取，便于使用Docker/Kubernetes secret
+func LoadConfig(configPath string, overrides ...string) (*Config, error) {
+	cfg, err := Load(configPath, overrides...)
+	if err != nil {
+		return nil, err
 	}
-	if config.Redis.PoolSize == 0 {
-		config.Redis.PoolSize = 10
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	if config.Redis.MinIdleConns == 0 {
-		config.Redis.MinIdleConns = 5
+	return cfg, nil
+}
+
+// Load 按层加载配置但不校验，用于只需要部分配置的命令和 config check
+func Load(configPath string, overrides ...string) (*Config, error) {
+	// 先填入默认值，配置文件、环境变量和命令行只覆盖其中明确设置的配置项
+	config := defaultConfig()
+	raw := map[string]interface{}{}
+	if configPath != "" {
+		// 读取配置文件
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+
+		// 解析YAML
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		}
+		// 再解析一次原始结构，用于读取敏感字段的 <key>_file
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		}
 	}
 
-	// 安全默认配置
-	if config.Security.SessionTimeout == 0 {
-		config.Security.SessionTimeout = 3600
+	if err := applySecretFiles(&config, raw); err != nil {
+		return nil, err
 	}
-	if config.Security.SessionName == "" {
-		config.Security.SessionName = "sessionid"
+	if err := applyEnv(&config, os.LookupEnv); err != nil {
+		return nil, err
 	}
-	if config.Security.CookieName == "" {
-		config.Security.CookieName = "login_session"
+	if err := applyOverrides(&config, overrides); err != nil {
+		return nil, err
 	}
-	if config.Security.JWTExpiry == 0 {
-		config.Security.JWTExpiry = 86400
+
+	return &config, nil
+}
+
+// defaultConfig 代码中的默认配置，是逐层覆盖的第一层
+// 之后各层只覆盖明确设置的配置项，显式设置的0或空值（例如 tracing.sample_ratio: 0）不会被默认值替换
+func defaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			Host:            "0.0.0.0",
+			ShutdownTimeout: 30,
+			RPCMaxBatchSize: 20,
+		},
+		Database: DatabaseConfig{
+			Driver: "mysql",
+		},
+		Redis: RedisConfig{
+			Host:         "localhost",
+			Port:         6379,
+			PoolSize:     10,
+			MinIdleConns: 5,
+		},
+		Security: SecurityConfig{
+			SessionTimeout: 3600,
+			SessionName:    "sessionid",
+			CookieName:     "login_session",
+			CookieSameSite: "lax",
+			SessionStore:   "redis",
+			JWTExpiry:      86400,
+		},
+		RateLimit: RateLimitConfig{
+			Backend:   "redis",
+			KeyPrefix: "ratelimit",
+		},
+		Logging: LoggingConfig{
+			Dir:          "bin/logs",
+			MaxAge:       7,
+			RotationTime: 24,
+		},
+		Metrics: MetricsConfig{
+			Path: "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4318",
+			ServiceName: "beast-royale-backend",
+			SampleRatio: 1,
+		},
+		Recording: RecordingConfig{
+			Dir: "fixtures",
+		},
+		Audit: AuditConfig{
+			RetentionDays: 365,
+			PruneInterval: 3600,
+		},
+		WebSocket: WebSocketConfig{
+			PingInterval:     25,
+			PongTimeout:      60,
+			WriteTimeout:     10,
+			MaxMessageSize:   64 * 1024,
+			SendQueueSize:    256,
+			MaxSubscriptions: 32,
+		},
+		EventBus: EventBusConfig{
+			Backend:     "memory",
+			Channel:     "events",
+			PresenceTTL: 90,
+		},
 	}
 }
 
@@ -145,11 +321,21 @@ func (c *Config) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Redis.Host, c.Redis.Port)
 }
 
+// UsesRedis 是否有组件使用Redis，都不使用时启动时不连接Redis
+func (c *Config) UsesRedis() bool {
+	return c.Security.SessionStore == "redis" || (c.RateLimit.Enabled && c.RateLimit.Backend == "redis") || c.EventBus.Backend == "redis"
+}
+
 // GetServerAddr 获取服务器地址
 func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
 
+// InMemoryDatabase 是否使用SQLite内存数据库，每次启动都是空的数据库
+func (c *Config) InMemoryDatabase() bool {
+	return c.Database.Driver == "sqlite" && c.Database.Path == ":memory:"
+}
+
 // GetDatabaseDSN 获取数据库连接字符串
 func (c *Config) GetDatabaseDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s",
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "server:\n  port: 9000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9000 {
+		t.Fatalf("server.port = %d, want 9000", cfg.Server.Port)
+	}
+	// 未设置的配置项使用默认值
+	if cfg.Server.Host != "0.0.0.0" || cfg.Tracing.SampleRatio != 1 || cfg.Audit.RetentionDays != 365 {
+		t.Fatalf("defaults not applied: host=%q sample_ratio=%v retention_days=%d",
+			cfg.Server.Host, cfg.Tracing.SampleRatio, cfg.Audit.RetentionDays)
+	}
+}
+
+func TestLoadKeepsExplicitZero(t *testing.T) {
+	tests := map[string]struct {
+		file      string
+		env       string
+		overrides []string
+	}{
+		"file":      {file: "tracing:\n  sample_ratio: 0\naudit:\n  retention_days: 0\n"},
+		"env":       {env: "0"},
+		"overrides": {overrides: []string{"tracing.sample_ratio=0", "audit.retention_days=0"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("BEAST_TRACING_SAMPLE_RATIO", tt.env)
+				t.Setenv("BEAST_AUDIT_RETENTION_DAYS", tt.env)
+			}
+			cfg, err := Load(writeConfig(t, tt.file), tt.overrides...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Tracing.SampleRatio != 0 {
+				t.Fatalf("tracing.sample_ratio = %v, want 0", cfg.Tracing.SampleRatio)
+			}
+			if cfg.Audit.RetentionDays != 0 {
+				t.Fatalf("audit.retention_days = %d, want 0", cfg.Audit.RetentionDays)
+			}
+			// 显式的0不再被默认值掩盖，由校验报错
+			if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "audit.retention_days") {
+				t.Fatalf("audit.retention_days: 0 should fail validation, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscriber 配置重新加载后的回调，返回错误不影响其他订阅者
+type Subscriber func(cfg *Config) error
+
+type subscriber struct {
+	name string
+	fn   Subscriber
+}
+
+// ReloadResult 一次重新加载的结果
+type ReloadResult struct {
+	// Reloaded 已经生效的配置项
+	Reloaded []string
+	// RestartRequired 已修改但需要重启才能生效的配置项，这些配置项继续使用旧值
+	RestartRequired []string
+}
+
+// Holder 持有当前生效的配置，重新加载时原子替换并通知订阅者
+// 读取方每次通过Get获取，不要长期持有返回的指针
+type Holder struct {
+	current atomic.Pointer[Config]
+
+	// mu 串行化重新加载和订阅
+	mu          sync.Mutex
+	path        string
+	overrides   []string
+	subscribers []subscriber
+}
+
+// Default 进程使用的配置，由InitConfig加载
+var Default = &Holder{}
+
+// NewHolder 创建Holder，path和overrides用于重新加载
+func NewHolder(cfg *Config, path string, overrides []string) *Holder {
+	h := &Holder{}
+	h.set(cfg, path, overrides)
+	return h
+}
+
+// set 设置初始配置，保留已有的订阅者
+func (h *Holder) set(cfg *Config, path string, overrides []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.path = path
+	h.overrides = overrides
+	h.current.Store(cfg)
+}
+
+// Get 获取当前生效的配置
+func Get() *Config {
+	return Default.Get()
+}
+
+// Get 获取当前生效的配置
+func (h *Holder) Get() *Config {
+	return h.current.Load()
+}
+
+// Path 配置文件路径
+func (h *Holder) Path() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.path
+}
+
+// Subscribe 订阅配置变化，只在可以重新加载的配置项变化时调用
+func (h *Holder) Subscribe(name string, fn Subscriber) {
+	h.mu.Lock()
+	h.subscribers = append(h.subscribers, subscriber{name: name, fn: fn})
+	h.mu.Unlock()
+}
+
+// Reload 重新加载配置文件、环境变量和secret文件
+// 新配置校验失败时保持当前配置不变；需要重启的配置项继续使用旧值，并在结果中列出
+func (h *Holder) Reload() (*ReloadResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	loaded, err := LoadConfig(h.path, h.overrides...)
+	if err != nil {
+		return nil, err
+	}
+	old := h.current.Load()
+	merged := mergeReloadable(old, loaded)
+	result := &ReloadResult{
+		Reloaded:        changedKeys(old, merged),
+		RestartRequired: changedKeys(merged, loaded),
+	}
+	if len(result.Reloaded) == 0 {
+		return result, nil
+	}
+
+	h.current.Store(merged)
+	var errs []error
+	for _, sub := range h.subscribers {
+		if err := sub.fn(merged); err != nil {
+			errs = append(errs, fmt.Errorf("apply config to %s: %w", sub.name, err))
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// mergeReloadable 在当前配置的副本上应用新配置中可以重新加载的部分
+// 新增可以重新加载的配置项时，需要同时在这里复制并由对应的订阅者应用
+// 配置中还没有游戏数值，以后加入时也需要在这里复制才能重新加载
+func mergeReloadable(old, loaded *Config) *Config {
+	merged := *old
+
+	merged.Logging.Level = loaded.Logging.Level
+	merged.Logging.Trace = loaded.Logging.Trace
+	merged.Logging.MaxFieldSize = loaded.Logging.MaxFieldSize
+	merged.Logging.MaxBodySize = loaded.Logging.MaxBodySize
+
+	merged.CORS = loaded.CORS
+
+	merged.Security.CookieSecure = loaded.Security.CookieSecure
+	merged.Security.CookieSameSite = loaded.Security.CookieSameSite
+	merged.Security.CookieDomain = loaded.Security.CookieDomain
+
+	merged.RateLimit.Default = loaded.RateLimit.Default
+	merged.RateLimit.Actions = loaded.RateLimit.Actions
+
+	return &merged
+}
+
+// changedKeys 比较两个配置，返回不同的配置项路径，例如 server.port
+// 敏感配置项也只返回路径，不包含值
+func changedKeys(a, b *Config) []string {
+	var keys []string
+	diffValue(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), nil, &keys)
+	return keys
+}
+
+func diffValue(a, b reflect.Value, path []string, keys *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*keys = append(*keys, strings.Join(path, "."))
+		}
+		return
+	}
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		diffValue(a.Field(i), b.Field(i), append(append([]string{}, path...), name), keys)
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ENV_PREFIX 覆盖配置的环境变量前缀
+const ENV_PREFIX = "BEAST_"
+
+// SECRET_MASK 输出配置时敏感字段的替代值
+const SECRET_MASK = "******"
+
+// field 一个可以被环境变量和命令行覆盖的配置项
+type field struct {
+	path   []string // yaml key路径，例如 [database password]
+	value  reflect.Value
+	secret bool
+}
+
+// key 点分隔的配置路径，例如 database.password
+func (f *field) key() string {
+	return strings.Join(f.path, ".")
+}
+
+// envName 对应的环境变量名，例如 BEAST_DATABASE_PASSWORD
+func (f *field) envName() string {
+	return ENV_PREFIX + strings.ToUpper(strings.Join(f.path, "_"))
+}
+
+// fields 列出配置中所有的叶子配置项
+// map和指针类型的配置（例如按Action的限流规则）结构不固定，只能通过配置文件设置
+func fields(cfg *Config) []field {
+	var result []field
+	collectFields(reflect.ValueOf(cfg).Elem(), nil, &result)
+	return result
+}
+
+func collectFields(v reflect.Value, path []string, result *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), name)
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.Struct:
+			collectFields(fv, fieldPath, result)
+		case reflect.Map, reflect.Ptr:
+			continue
+		default:
+			*result = append(*result, field{path: fieldPath, value: fv, secret: sf.Tag.Get("secret") == "true"})
+		}
+	}
+}
+
+// setValue 把字符串解析为配置项的类型，数组使用逗号分隔
+func setValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// readSecretFile 读取secret文件，去掉末尾的换行
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// applySecretFiles 处理配置文件中敏感字段的 <key>_file，与 <key> 同时配置时报错
+func applySecretFiles(cfg *Config, raw map[string]interface{}) error {
+	for _, f := range fields(cfg) {
+		if !f.secret {
+			continue
+		}
+		section := raw
+		for _, name := range f.path[:len(f.path)-1] {
+			next, ok := section[name].(map[string]interface{})
+			if !ok {
+				section = nil
+				break
+			}
+			section = next
+		}
+		name := f.path[len(f.path)-1]
+		path, ok := section[name+"_file"].(string)
+		if !ok || path == "" {
+			continue
+		}
+		if f.value.String() != "" {
+			return fmt.Errorf("%s and %s_file are both set", f.key(), f.key())
+		}
+		secret, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s_file: %w", f.key(), err)
+		}
+		f.value.SetString(secret)
+	}
+	return nil
+}
+
+// applyEnv 使用环境变量覆盖配置，敏感字段支持 BEAST_<KEY>_FILE
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	for _, f := range fields(cfg) {
+		name := f.envName()
+		raw, ok := lookup(name)
+		if f.secret {
+			if path, fileOK := lookup(name + "_FILE"); fileOK && path != "" {
+				if ok {
+					return fmt.Errorf("%s and %s_FILE are both set", name, name)
+				}
+				secret, err := readSecretFile(path)
+				if err != nil {
+					return fmt.Errorf("read %s_FILE: %w", name, err)
+				}
+				raw, ok = secret, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			return fmt.Errorf("environment %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyOverrides 应用命令行传入的 key=value
+func applyOverrides(cfg *Config, overrides []string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	byKey := make(map[string]field)
+	for _, f := range fields(cfg) {
+		byKey[f.key()] = f
+	}
+	for _, override := range overrides {
+		key, raw, ok := strings.Cut(override, "=")
+		if !ok {
+			return fmt.Errorf("invalid override %q, expected key=value", override)
+		}
+		f, ok := byKey[strings.TrimSpace(key)]
+		if !ok {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+		if err := setValue(f.value, raw); err != nil {
+			return fmt.Errorf("override %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Masked 返回隐藏了敏感字段的配置副本，用于输出
+func (c *Config) Masked() *Config {
+	masked := *c
+	for _, f := range fields(&masked) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(SECRET_MASK)
+		}
+	}
+	return &masked
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MIN_SESSION_SECRET_LEN session密钥的最小长度
+const MIN_SESSION_SECRET_LEN = 16
+
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+	}
+
+	check(validPort(c.Server.Port), "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Server.RPCMaxBatchSize > 0, "server.rpc_max_batch_size must be positive")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies: invalid IP or CIDR %q", proxy)
+	}
+
+	if c.UsesRedis() {
+		check(c.Redis.Host != "", "redis.host is required")
+		check(validPort(c.Redis.Port), "redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+		check(c.Redis.PoolSize > 0, "redis.pool_size must be positive")
+	}
+
+	oneOf("database.driver", c.Database.Driver, "mysql", "sqlite")
+	switch c.Database.Driver {
+	case "mysql":
+		check(c.Database.Host != "", "database.host is required")
+		check(validPort(c.Database.Port), "database.port must be between 1 and 65535, got %d", c.Database.Port)
+		check(c.Database.User != "", "database.user is required")
+		check(c.Database.Password != "", "database.password is required (set database.password_file or BEAST_DATABASE_PASSWORD)")
+		check(c.Database.DBName != "", "database.dbname is required")
+	case "sqlite":
+		check(c.Database.Path != "", "database.path is required for sqlite")
+	}
+
+	check(c.Security.SessionSecret != "" || c.Security.SessionKeyring != "",
+		"security.session_keyring or security.session_secret is required (generate a keyring with `session-keys generate`)")
+	check(c.Security.SessionSecret == "" || len(c.Security.SessionSecret) >= MIN_SESSION_SECRET_LEN,
+		"security.session_secret must be at least %d bytes", MIN_SESSION_SECRET_LEN)
+	oneOf("security.session_store", c.Security.SessionStore, "redis", "memory", "cookie")
+	// session_secret只签名不加密，cookie存储会把session数据明文暴露给客户端
+	check(c.Security.SessionStore != "cookie" || c.Security.SessionKeyring != "",
+		"security.session_store cookie requires security.session_keyring (session_secret does not encrypt cookies)")
+	check(c.Security.SessionTimeout > 0, "security.session_timeout must be positive")
+	oneOf("security.cookie_same_site", strings.ToLower(c.Security.CookieSameSite), "lax", "strict", "none")
+	check(!strings.EqualFold(c.Security.CookieSameSite, "none") || c.Security.CookieSecure,
+		"security.cookie_same_site none requires security.cookie_secure")
+	for name, hash := range c.Security.AdminAPIKeys {
+		decoded, err := hex.DecodeString(hash)
+		check(name != "" && err == nil && len(decoded) == sha256.Size,
+			"security.admin_api_keys.%s must be the hex SHA-256 of the key (generate one with `admin key generate`)", name)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			errs = append(errs, err)
+		}
+		check(origin != "*" || !c.CORS.AllowCredentials, "cors.allowed_origins \"*\" cannot be used with cors.allow_credentials")
+	}
+
+	if c.Logging.Level != "" {
+		oneOf("logging.level", strings.ToLower(c.Logging.Level), "debug", "info", "warn", "error")
+	}
+	if c.Logging.Format != "" {
+		oneOf("logging.format", strings.ToLower(c.Logging.Format), "text", "json")
+	}
+	if c.Logging.Output != "" {
+		oneOf("logging.output", strings.ToLower(c.Logging.Output), "stdout", "file", "both")
+	}
+
+	oneOf("rate_limit.backend", c.RateLimit.Backend, "redis", "memory")
+	oneOf("event_bus.backend", c.EventBus.Backend, "memory", "redis")
+	check(c.EventBus.PresenceTTL > 0, "event_bus.presence_ttl must be positive")
+
+	if c.Metrics.Enabled {
+		check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path must start with /, got %q", c.Metrics.Path)
+		for _, allow := range c.Metrics.AllowIPs {
+			_, _, err := net.ParseCIDR(allow)
+			check(err == nil || net.ParseIP(allow) != nil, "metrics.allow_ips: invalid IP or CIDR %q", allow)
+		}
+	}
+
+	if c.Tracing.Enabled {
+		oneOf("tracing.exporter", strings.ToLower(c.Tracing.Exporter), "otlp", "stdout")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+
+	check(c.WebSocket.PingInterval < c.WebSocket.PongTimeout, "websocket.ping_interval must be less than websocket.pong_timeout")
+	check(c.WebSocket.MaxSubscriptions > 0, "websocket.max_subscriptions must be positive")
+
+	check(c.Audit.RetentionDays > 0 || c.Audit.RetentionDays == -1, "audit.retention_days must be positive or -1, got %d", c.Audit.RetentionDays)
+	check(c.Audit.PruneInterval > 0, "audit.prune_interval must be positive")
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
+
+// validateOrigin 来源只允许 * 或 scheme://host[:port]，通配符只能作为最左侧的子域名，例如 https://*.example.com
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme == "" || host == "" || strings.ContainsAny(host, "/?#") {
+		return fmt.Errorf("cors.allowed_origins: invalid origin %q, expected scheme://host[:port]", origin)
+	}
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return fmt.Errorf("cors.allowed_origins: invalid origin %q, wildcard is only allowed as the leftmost subdomain like https://*.example.com", origin)
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WATCH_DEBOUNCE 配置文件变化后等待的时间，编辑器保存时通常会连续产生多个事件
+const WATCH_DEBOUNCE = 500 * time.Millisecond
+
+// Watch 监听配置文件变化，文件变化时调用onChange，ctx取消后停止
+// 监听的是配置文件所在的目录，编辑器先写临时文件再重命名、Kubernetes ConfigMap替换..data软链接都能被发现
+func (h *Holder) Watch(ctx context.Context, onChange func()) error {
+	path := h.Path()
+	if path == "" {
+		return errors.New("no config file to watch")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if isConfigEvent(event, path) {
+					debounce = time.After(WATCH_DEBOUNCE)
+				}
+			case <-watcher.Errors:
+			case <-debounce:
+				debounce = nil
+				onChange()
+			}
+		}
+	}()
+	return nil
+}
+
+// isConfigEvent 事件是否可能改变了配置文件的内容
+func isConfigEvent(event fsnotify.Event, path string) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return name == path || strings.HasPrefix(filepath.Base(name), "..")
+}
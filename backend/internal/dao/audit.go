@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 审计记录的操作人类型
+const (
+	ACTOR_ADDRESS = "address" // 已登录的玩家，Actor为钱包地址
+	ACTOR_API_KEY = "api_key" // 使用管理密钥的调用方，Actor为密钥名称
+	ACTOR_ADMIN   = "admin"   // admin 命令的操作人，Actor为 --actor
+	ACTOR_SYSTEM  = "system"  // 服务自身，例如首次登录时创建档案
+)
+
+// AuditEvent 一条审计记录，只追加不修改，超过保留期后按时间批量删除
+type AuditEvent struct {
+	ID          int64        `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	ActorType   string       `gorm:"type:varchar(16)" json:"actor_type"`
+	Actor       string       `gorm:"type:varchar(64)" json:"actor"`
+	Action      string       `gorm:"type:varchar(64)" json:"action"`
+	Target      string       `gorm:"type:varchar(64)" json:"target"`       // 被修改的账户地址
+	Changes     AuditChanges `gorm:"type:text" json:"changes"`             // 修改前后的字段值
+	Reason      string       `gorm:"type:varchar(255)" json:"reason"`      // admin 命令的 --reason
+	IP          string       `gorm:"type:varchar(45)" json:"ip"`           // 请求的客户端IP，admin 命令为空
+	RequestUUID string       `gorm:"type:varchar(64)" json:"request_uuid"` // 请求的RequestUUID，可以与日志关联
+}
+
+// TableName 设置表名
+func (AuditEvent) TableName() string {
+	return "audit_event"
+}
+
+// AuditChange 一个字段修改前后的值，新建或删除时对应的一侧为空
+type AuditChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// AuditChanges 以JSON保存在一个text列中
+type AuditChanges []AuditChange
+
+// Value 实现driver.Valuer
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		c = AuditChanges{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported audit changes type %T", value)
+	}
+	return json.Unmarshal(data, c)
+}
@@ -6,7 +6,7 @@ type UserProfile struct {
 	Address            string     `gorm:"primaryKey;type:varchar(42)" json:"address"`
 	Username           string     `gorm:"type:varchar(42);unique" json:"username,omitempty"`
 	Bio                string     `gorm:"type:varchar(500)" json:"bio,omitempty"`
-	AvatarURL          string     `gorm:"type:varchar(50)" json:"avatar_url,omitempty"`
+	AvatarURL          string     `gorm:"type:varchar(255)" json:"avatar_url,omitempty"`
 	DiscordURL         string     `gorm:"type:varchar(100)" json:"discord_url,omitempty"`
 	DiscordUsername    string     `gorm:"type:varchar(50)" json:"discord_username,omitempty"`
 	XURL               string     `gorm:"type:varchar(100)" json:"x_url,omitempty"`
@@ -16,6 +16,12 @@ type UserProfile struct {
 	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`                   // 创建时间
 	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`                   // 更新时间
 	LastUsernameUpdate *time.Time `gorm:"default:NULL" json:"last_username_update,omitempty"` // 上次更新用户名的时间，使用指针避免零值问题
+	SuspendedAt        *time.Time `gorm:"default:NULL" json:"suspended_at,omitempty"`         // 封禁时间，为空表示未封禁
+}
+
+// Suspended 账户是否被封禁
+func (p *UserProfile) Suspended() bool {
+	return p.SuspendedAt != nil
 }
 
 // TableName 设置表名
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/dao"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter 查询审计记录的条件，空值表示不限制
+type AuditFilter struct {
+	ActorType   string
+	Actor       string
+	Action      string
+	Target      string
+	RequestUUID string
+	Since       time.Time // 包含
+	Until       time.Time // 不包含
+	BeforeID    int64     // 分页，只返回ID小于该值的记录
+	Limit       int
+}
+
+// AuditRepository 审计记录只能追加，不能修改；超过保留期的记录通过DeleteBefore批量删除
+type AuditRepository interface {
+	Append(ctx context.Context, event *dao.AuditEvent) error
+	// Find 按ID从新到旧返回记录
+	Find(ctx context.Context, filter AuditFilter) ([]dao.AuditEvent, error)
+	// DeleteBefore 删除创建时间早于cutoff的记录，每次最多删除batch条，返回删除的条数
+	DeleteBefore(ctx context.Context, cutoff time.Time, batch int) (int64, error)
+}
+
+// auditRepository 基于GORM的审计记录仓库
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// Append 追加审计记录，地址统一按小写保存
+func (r *auditRepository) Append(ctx context.Context, event *dao.AuditEvent) error {
+	event.Target = normalizeAddress(event.Target)
+	if event.ActorType == dao.ACTOR_ADDRESS {
+		event.Actor = normalizeAddress(event.Actor)
+	}
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// Find 查询审计记录
+func (r *auditRepository) Find(ctx context.Context, filter AuditFilter) ([]dao.AuditEvent, error) {
+	q := r.db.WithContext(ctx).Model(&dao.AuditEvent{})
+	if filter.ActorType != "" {
+		q = q.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.Actor != "" {
+		q = q.Where("actor = ?", normalizeAddress(filter.Actor))
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		q = q.Where("target = ?", normalizeAddress(filter.Target))
+	}
+	if filter.RequestUUID != "" {
+		q = q.Where("request_uuid = ?", filter.RequestUUID)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("created_at < ?", filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		q = q.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	var events []dao.AuditEvent
+	err := q.Order("id DESC").Find(&events).Error
+	return events, err
+}
+
+// DeleteBefore 分批删除过期记录，避免一次删除大量数据时长时间锁表
+func (r *auditRepository) DeleteBefore(ctx context.Context, cutoff time.Time, batch int) (int64, error) {
+	// MySQL不支持在IN子查询中使用LIMIT，先查出ID再删除
+	var list []int64
+	err := r.db.WithContext(ctx).Model(&dao.AuditEvent{}).
+		Where("created_at < ?", cutoff).Order("id").Limit(batch).Pluck("id", &list).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Where("id IN ?", list).Delete(&dao.AuditEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// normalizeAddress 0x开头的地址转换为小写，其余原样返回
+func normalizeAddress(s string) string {
+	if strings.HasPrefix(strings.ToLower(s), "0x") {
+		return strings.ToLower(s)
+	}
+	return s
+}
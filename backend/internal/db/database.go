@@ -1,11 +1,16 @@
 package db
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 
 	"beast-royale-backend/internal/config"
-	"beast-royale-backend/internal/dao"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+	"beast-royale-backend/internal/tracing"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -17,32 +22,78 @@ func Init() error {
 	var err error
 
 	// 使用配置文件中的数据库连接信息
-	dsn := config.GConf.GetDatabaseDSN()
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	DB, err = Open(config.Get())
 	if err != nil {
 		return err
 	}
 
-	// 自动迁移数据库表
-	err = DB.AutoMigrate(
-		&dao.UserProfile{},
-	)
-	if err != nil {
+	// 统计数据库操作耗时和错误，并为每次操作创建span
+	if err = metrics.InstrumentGORM(DB); err != nil {
+		return err
+	}
+	if err = tracing.InstrumentGORM(DB); err != nil {
 		return err
 	}
 
-	log.Println("Database initialized successfully")
+	// 表结构由 db-migrate 命令迁移，启动时不修改表结构
+	logger.Info("Database initialized successfully - Driver: %s", config.Get().Database.Driver)
 	return nil
 }
 
+// Open 按database.driver打开数据库连接
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	switch cfg.Database.Driver {
+	case "mysql":
+		return gorm.Open(mysql.Open(cfg.GetDatabaseDSN()), &gorm.Config{})
+	case "sqlite":
+		return openSQLite(cfg.Database.Path)
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.Database.Driver)
+	}
+}
+
+// openSQLite 打开SQLite数据库，使用纯Go实现的驱动，不依赖cgo
+// SQLite同一时间只允许一个写入，连接池限制为一个连接，避免并发写入时出现 database is locked；
+// :memory: 数据库每个连接各自独立，也需要只使用一个连接
+func openSQLite(path string) (*gorm.DB, error) {
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return gdb, nil
+}
+
+// Ping 检查数据库连接是否可用，用于就绪检查
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close 关闭数据库连接池
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return DB
 }
-
-// Migrate 用于命令行迁移表结构
-func Migrate() error {
-	return DB.AutoMigrate(
-		&dao.UserProfile{},
-	)
-}
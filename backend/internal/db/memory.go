@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"beast-royale-backend/internal/dao"
+)
+
+// errDuplicateUsername 用户名已被其他档案使用，对应数据库中username的唯一索引
+var errDuplicateUsername = errors.New("duplicate username")
+
+// memoryData MemoryStore保存的数据
+type memoryData struct {
+	profiles map[string]dao.UserProfile
+	events   []dao.AuditEvent
+	nextID   int64
+}
+
+func (d *memoryData) clone() *memoryData {
+	profiles := make(map[string]dao.UserProfile, len(d.profiles))
+	for k, v := range d.profiles {
+		profiles[k] = v
+	}
+	return &memoryData{
+		profiles: profiles,
+		events:   append([]dao.AuditEvent(nil), d.events...),
+		nextID:   d.nextID,
+	}
+}
+
+// MemoryStore 进程内的Store，任务的单元测试使用，不需要数据库
+// 事务在数据的副本上执行，成功后整体替换，事务之间串行执行
+type MemoryStore struct {
+	txMu sync.Mutex // 串行化事务
+	mu   sync.Mutex
+	data *memoryData
+	now  func() time.Time
+}
+
+// NewMemoryStore 创建空的内存Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: &memoryData{profiles: make(map[string]dao.UserProfile), nextID: 1},
+		now:  time.Now,
+	}
+}
+
+func (s *MemoryStore) UserProfiles() UserProfileRepository {
+	return &memoryProfiles{s}
+}
+
+func (s *MemoryStore) AuditEvents() AuditRepository {
+	return &memoryAudit{s}
+}
+
+func (s *MemoryStore) RunInTx(ctx context.Context, dryRun bool, fn func(tx Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	tx := &MemoryStore{data: s.data.clone(), now: s.now}
+	s.mu.Unlock()
+
+	if err := fn(tx); err != nil || dryRun {
+		return err
+	}
+	s.mu.Lock()
+	s.data = tx.data
+	s.mu.Unlock()
+	return nil
+}
+
+type memoryProfiles struct {
+	s *MemoryStore
+}
+
+func (r *memoryProfiles) GetByAddress(ctx context.Context, address string) (*dao.UserProfile, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	profile, ok := r.s.data.profiles[strings.ToLower(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &profile, nil
+}
+
+func (r *memoryProfiles) GetByUsername(ctx context.Context, username string) (*dao.UserProfile, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, profile := range r.s.data.profiles {
+		if profile.Username == username {
+			return &profile, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryProfiles) Create(ctx context.Context, profile *dao.UserProfile) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.data.profiles[profile.Address]; ok {
+		return fmt.Errorf("profile %s already exists", profile.Address)
+	}
+	now := r.s.now()
+	profile.CreatedAt, profile.UpdatedAt = now, now
+	return r.s.save(profile)
+}
+
+func (r *memoryProfiles) Update(ctx context.Context, profile *dao.UserProfile) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	profile.UpdatedAt = r.s.now()
+	return r.s.save(profile)
+}
+
+func (r *memoryProfiles) Delete(ctx context.Context, address string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.data.profiles, strings.ToLower(address))
+	return nil
+}
+
+// save 保存档案，检查用户名唯一，调用方需持有锁
+func (s *MemoryStore) save(profile *dao.UserProfile) error {
+	for address, other := range s.data.profiles {
+		if address != profile.Address && other.Username == profile.Username {
+			return fmt.Errorf("%w: %s", errDuplicateUsername, profile.Username)
+		}
+	}
+	s.data.profiles[profile.Address] = *profile
+	return nil
+}
+
+type memoryAudit struct {
+	s *MemoryStore
+}
+
+func (r *memoryAudit) Append(ctx context.Context, event *dao.AuditEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event.Target = normalizeAddress(event.Target)
+	if event.ActorType == dao.ACTOR_ADDRESS {
+		event.Actor = normalizeAddress(event.Actor)
+	}
+	event.ID = r.s.data.nextID
+	event.CreatedAt = r.s.now()
+	r.s.data.nextID++
+	r.s.data.events = append(r.s.data.events, *event)
+	return nil
+}
+
+func (r *memoryAudit) Find(ctx context.Context, filter AuditFilter) ([]dao.AuditEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var events []dao.AuditEvent
+	for _, e := range r.s.data.events {
+		switch {
+		case filter.ActorType != "" && e.ActorType != filter.ActorType,
+			filter.Actor != "" && e.Actor != normalizeAddress(filter.Actor),
+			filter.Action != "" && e.Action != filter.Action,
+			filter.Target != "" && e.Target != normalizeAddress(filter.Target),
+			filter.RequestUUID != "" && e.RequestUUID != filter.RequestUUID,
+			!filter.Since.IsZero() && e.CreatedAt.Before(filter.Since),
+			!filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until),
+			filter.BeforeID > 0 && e.ID >= filter.BeforeID:
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID > events[j].ID })
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+func (r *memoryAudit) DeleteBefore(ctx context.Context, cutoff time.Time, batch int) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	kept := r.s.data.events[:0]
+	var deleted int64
+	for _, e := range r.s.data.events {
+		if e.CreatedAt.Before(cutoff) && deleted < int64(batch) {
+			deleted++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	r.s.data.events = kept
+	return deleted, nil
+}
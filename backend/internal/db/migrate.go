@@ -0,0 +1,446 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/db/migrations"
+	"beast-royale-backend/internal/logger"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// MIGRATIONS_TABLE 记录已执行迁移的表
+const MIGRATIONS_TABLE = "schema_migrations"
+
+// MIGRATION_LOCK_NAME MySQL中迁移使用的命名锁，SQLite使用同名的表
+const MIGRATION_LOCK_NAME = "schema_migrations_lock"
+
+// DEFAULT_MIGRATION_LOCK_TIMEOUT 等待其他实例完成迁移的默认时间
+const DEFAULT_MIGRATION_LOCK_TIMEOUT = 60 * time.Second
+
+// ErrMigrationLocked 其他实例正在执行迁移
+var ErrMigrationLocked = errors.New("another migration is in progress")
+
+// migrationFileName 迁移文件名，例如 0002_widen_avatar_url.up.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 一个版本的迁移状态
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // 为空表示未执行
+	// Missing 数据库中已执行但当前二进制中没有对应的迁移文件，通常是旧版本的服务连接了已经升级的数据库
+	Missing bool
+}
+
+// appliedMigration schema_migrations中的一行
+type appliedMigration struct {
+	Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (appliedMigration) TableName() string {
+	return MIGRATIONS_TABLE
+}
+
+// Migrator 执行内嵌的SQL迁移，执行前获取锁，多个实例不会同时迁移
+// SQLite的迁移在事务中执行，失败时整体回滚；MySQL的DDL会隐式提交，迁移中途失败时需要检查表结构后再重试
+type Migrator struct {
+	db          *gorm.DB
+	driver      string
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// NewMigrator 使用数据库连接和驱动对应的迁移文件创建Migrator
+func NewMigrator(gdb *gorm.DB, driver string) (*Migrator, error) {
+	list, err := LoadMigrations(migrations.FS, driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:          gdb,
+		driver:      driver,
+		migrations:  list,
+		LockTimeout: DEFAULT_MIGRATION_LOCK_TIMEOUT,
+	}, nil
+}
+
+// LoadMigrations 读取dir目录下的迁移文件，按版本号排序，每个版本都需要up和down两个文件
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %s: %w", dir, err)
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s/%s, expected <version>_<name>.up.sql or .down.sql", dir, entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s requires both up and down files", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Migrations 所有的迁移，按版本号排序
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status 所有迁移的执行状态，包括数据库中有但文件中没有的版本
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []MigrationStatus
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(applied, mig.Version)
+		}
+		result = append(result, status)
+	}
+	for _, a := range applied {
+		appliedAt := a.AppliedAt
+		result = append(result, MigrationStatus{Version: a.Version, Name: a.Name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Pending 未执行的迁移
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up 按版本号顺序执行所有未执行的迁移，返回执行了的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range pending {
+			if err := m.apply(ctx, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本号从新到旧回滚steps个已执行的迁移，返回回滚了的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.appliedDesc(ctx)
+		if err != nil {
+			return err
+		}
+		if steps < len(applied) {
+			applied = applied[:steps]
+		}
+		for _, mig := range applied {
+			if err := m.apply(ctx, mig, false); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To 迁移到指定版本：执行不超过该版本的未执行迁移，回滚高于该版本的已执行迁移
+// version为0时回滚所有迁移；返回执行或回滚了的迁移，up表示是否为执行
+func (m *Migrator) To(ctx context.Context, version int64) (done []Migration, up bool, err error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, false, fmt.Errorf("migration version %d not found", version)
+	}
+	err = m.withLock(ctx, func() error {
+		applied, err := m.appliedDesc(ctx)
+		if err != nil {
+			return err
+		}
+		var rollback []Migration
+		for _, mig := range applied {
+			if mig.Version > version {
+				rollback = append(rollback, mig)
+			}
+		}
+		if len(rollback) > 0 {
+			for _, mig := range rollback {
+				if err := m.apply(ctx, mig, false); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+			return nil
+		}
+
+		up = true
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range pending {
+			if mig.Version > version {
+				break
+			}
+			if err := m.apply(ctx, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, up, err
+}
+
+// apply 在事务中执行一个迁移并更新schema_migrations
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) error {
+	script, direction := mig.Up, "up"
+	if !up {
+		script, direction = mig.Down, "down"
+	}
+	start := time.Now()
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(&appliedMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()}).Error
+		}
+		return tx.Delete(&appliedMigration{Version: mig.Version}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", mig.Version, mig.Name, direction, err)
+	}
+	logger.Info("数据库迁移完成 - Version: %d, Name: %s, Direction: %s, Duration: %v", mig.Version, mig.Name, direction, time.Since(start))
+	return nil
+}
+
+// applied 已执行的迁移，只读取不修改表结构，schema_migrations表不存在时表示没有执行过任何迁移
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	if !m.db.WithContext(ctx).Migrator().HasTable(MIGRATIONS_TABLE) {
+		return map[int64]appliedMigration{}, nil
+	}
+	var rows []appliedMigration
+	if err := m.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// appliedDesc 已执行的迁移，按版本号从新到旧排序；缺少迁移文件时无法回滚，返回错误
+func (m *Migrator) appliedDesc(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Migration, 0, len(applied))
+	for version, a := range applied {
+		mig := m.find(version)
+		if mig == nil {
+			return nil, fmt.Errorf("migration %d_%s is applied but its files are missing, use a newer binary", version, a.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version > list[j].Version })
+	return list, nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// ensureTable 创建schema_migrations表，只在持有迁移锁、将要执行或回滚迁移时调用
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec("CREATE TABLE IF NOT EXISTS " + MIGRATIONS_TABLE +
+		" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at DATETIME NOT NULL)").Error
+}
+
+// withLock 持有迁移锁执行fn，等待超过LockTimeout时返回ErrMigrationLocked
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	var unlock func()
+	var err error
+	switch m.driver {
+	case "mysql":
+		unlock, err = m.lockMySQL(ctx)
+	default:
+		unlock, err = m.lockTable(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// lockMySQL 使用GET_LOCK，锁属于数据库连接，进程退出或连接断开时自动释放
+func (m *Migrator) lockMySQL(ctx context.Context) (func(), error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var got sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", MIGRATION_LOCK_NAME, int(m.LockTimeout.Seconds())).Scan(&got)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if !got.Valid || got.Int64 != 1 {
+		conn.Close()
+		return nil, fmt.Errorf("%w: waited %v for lock %s", ErrMigrationLocked, m.LockTimeout, MIGRATION_LOCK_NAME)
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", MIGRATION_LOCK_NAME)
+		conn.Close()
+	}, nil
+}
+
+// lockTable 没有命名锁的数据库（SQLite）通过向锁表插入同一个主键加锁
+// 进程异常退出时锁不会自动释放，确认没有实例在迁移后删除锁表中的记录
+func (m *Migrator) lockTable(ctx context.Context) (func(), error) {
+	gdb := m.db.WithContext(ctx)
+	err := gdb.Exec("CREATE TABLE IF NOT EXISTS " + MIGRATION_LOCK_NAME +
+		" (id INTEGER NOT NULL PRIMARY KEY, owner VARCHAR(255) NOT NULL, locked_at DATETIME NOT NULL)").Error
+	if err != nil {
+		return nil, err
+	}
+	// 锁被占用时插入失败是预期的，不输出错误日志
+	quiet := gdb.Session(&gorm.Session{Logger: gdb.Logger.LogMode(gormlogger.Silent)})
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%d", host, os.Getpid())
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		err = quiet.Exec("INSERT INTO "+MIGRATION_LOCK_NAME+" (id, owner, locked_at) VALUES (1, ?, ?)", owner, time.Now().UTC()).Error
+		if err == nil {
+			return func() {
+				m.db.Exec("DELETE FROM "+MIGRATION_LOCK_NAME+" WHERE id = 1 AND owner = ?", owner)
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			var holder struct {
+				Owner    string
+				LockedAt time.Time
+			}
+			gdb.Raw("SELECT owner, locked_at FROM " + MIGRATION_LOCK_NAME + " WHERE id = 1").Scan(&holder)
+			return nil, fmt.Errorf("%w: locked by %s since %s, delete the row from %s if that process is gone",
+				ErrMigrationLocked, holder.Owner, holder.LockedAt.Format(time.RFC3339), MIGRATION_LOCK_NAME)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// splitStatements 按行尾的分号拆分SQL语句，跳过空行和 -- 注释
+// MySQL驱动默认不允许一次执行多条语句，需要逐条执行
+func splitStatements(script string) []string {
+	var stmts []string
+	var b strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(b.String()), ";"))
+			b.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
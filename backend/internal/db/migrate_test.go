@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"beast-royale-backend/internal/config"
+)
+
+func TestPendingIsReadOnly(t *testing.T) {
+	gdb, err := Open(&config.Config{Database: config.DatabaseConfig{Driver: "sqlite", Path: ":memory:"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMigrator(gdb, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 检查未执行的迁移不创建schema_migrations表
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(m.Migrations()) {
+		t.Fatalf("got %d pending migrations, want %d", len(pending), len(m.Migrations()))
+	}
+	if _, err := m.Status(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if gdb.Migrator().HasTable(MIGRATIONS_TABLE) {
+		t.Fatalf("%s created by a read-only check", MIGRATIONS_TABLE)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := m.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Fatalf("pending after up: %v, %v", pending, err)
+	}
+}
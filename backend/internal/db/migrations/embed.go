@@ -0,0 +1,11 @@
+// Package migrations 内嵌到二进制中的数据库迁移文件
+//
+// 每个数据库驱动一个目录，文件名为 <版本号>_<名称>.up.sql 和 <版本号>_<名称>.down.sql，
+// 版本号递增且两个驱动保持一致。已经发布的迁移文件不要再修改，修改表结构时新增迁移。
+// 多条语句之间用行尾的分号分隔，MySQL的DDL不能在事务中回滚，每个迁移尽量只做一件事。
+package migrations
+
+import "embed"
+
+//go:embed mysql/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,18 @@
+-- 与之前AutoMigrate创建的表结构一致，已有的数据库执行时不会有变化
+CREATE TABLE IF NOT EXISTS `user_profile` (
+  `address` varchar(42) NOT NULL,
+  `username` varchar(42) DEFAULT NULL,
+  `bio` varchar(500) DEFAULT NULL,
+  `avatar_url` varchar(50) DEFAULT NULL,
+  `discord_url` varchar(100) DEFAULT NULL,
+  `discord_username` varchar(50) DEFAULT NULL,
+  `x_url` varchar(100) DEFAULT NULL,
+  `x_username` varchar(50) DEFAULT NULL,
+  `points` bigint DEFAULT 0,
+  `tokens` bigint DEFAULT 0,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  `last_username_update` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`address`),
+  UNIQUE KEY `uni_user_profile_username` (`username`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+-- 超过50个字符的头像地址需要先处理，否则回滚会失败
+ALTER TABLE `user_profile` MODIFY `avatar_url` varchar(50) DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- 头像地址超过50个字符时会被截断或写入失败
+ALTER TABLE `user_profile` MODIFY `avatar_url` varchar(255) DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- 回滚后已封禁的账户会被解封
+ALTER TABLE `user_profile` DROP COLUMN `suspended_at`;
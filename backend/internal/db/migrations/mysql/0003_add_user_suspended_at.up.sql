@@ -0,0 +1,2 @@
+-- 被封禁的账户不能登录，也不能读取或修改档案，由 admin user suspend 设置
+ALTER TABLE `user_profile` ADD COLUMN `suspended_at` datetime(3) DEFAULT NULL;
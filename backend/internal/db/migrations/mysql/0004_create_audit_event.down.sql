@@ -0,0 +1,2 @@
+-- 回滚会删除所有审计记录
+DROP TABLE IF EXISTS `audit_event`;
@@ -0,0 +1,18 @@
+-- 审计记录只追加，保留期由 audit.retention_days 控制
+CREATE TABLE IF NOT EXISTS `audit_event` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NOT NULL,
+  `actor_type` varchar(16) NOT NULL,
+  `actor` varchar(64) NOT NULL,
+  `action` varchar(64) NOT NULL,
+  `target` varchar(64) NOT NULL,
+  `changes` text,
+  `reason` varchar(255) DEFAULT NULL,
+  `ip` varchar(45) DEFAULT NULL,
+  `request_uuid` varchar(64) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_event_created_at` (`created_at`),
+  KEY `idx_audit_event_target` (`target`),
+  KEY `idx_audit_event_actor` (`actor`),
+  KEY `idx_audit_event_request_uuid` (`request_uuid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
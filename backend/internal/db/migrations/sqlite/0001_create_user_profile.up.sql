@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `user_profile` (
+  `address` varchar(42) NOT NULL,
+  `username` varchar(42),
+  `bio` varchar(500),
+  `avatar_url` varchar(50),
+  `discord_url` varchar(100),
+  `discord_username` varchar(50),
+  `x_url` varchar(100),
+  `x_username` varchar(50),
+  `points` integer DEFAULT 0,
+  `tokens` integer DEFAULT 0,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `last_username_update` datetime DEFAULT NULL,
+  PRIMARY KEY (`address`),
+  CONSTRAINT `uni_user_profile_username` UNIQUE (`username`)
+);
@@ -0,0 +1 @@
+-- SQLite不限制varchar的长度，只需要与MySQL保持相同的版本号
@@ -0,0 +1,17 @@
+-- 审计记录只追加，保留期由 audit.retention_days 控制
+CREATE TABLE IF NOT EXISTS `audit_event` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime NOT NULL,
+  `actor_type` varchar(16) NOT NULL,
+  `actor` varchar(64) NOT NULL,
+  `action` varchar(64) NOT NULL,
+  `target` varchar(64) NOT NULL,
+  `changes` text,
+  `reason` varchar(255),
+  `ip` varchar(45),
+  `request_uuid` varchar(64)
+);
+CREATE INDEX IF NOT EXISTS `idx_audit_event_created_at` ON `audit_event` (`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_audit_event_target` ON `audit_event` (`target`);
+CREATE INDEX IF NOT EXISTS `idx_audit_event_actor` ON `audit_event` (`actor`);
+CREATE INDEX IF NOT EXISTS `idx_audit_event_request_uuid` ON `audit_event` (`request_uuid`);
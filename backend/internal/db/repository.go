@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"beast-royale-backend/internal/dao"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 记录不存在，仓库实现（包括测试用的MemoryStore）查询不到记录时都返回该错误
+var ErrNotFound = errors.New("record not found")
+
+// UserProfileRepository 用户档案的存取，地址统一按小写处理
+type UserProfileRepository interface {
+	GetByAddress(ctx context.Context, address string) (*dao.UserProfile, error)
+	GetByUsername(ctx context.Context, username string) (*dao.UserProfile, error)
+	Create(ctx context.Context, profile *dao.UserProfile) error
+	Update(ctx context.Context, profile *dao.UserProfile) error
+	Delete(ctx context.Context, address string) error
+}
+
+// Store 任务访问数据的入口，由api.Deps注入任务
+type Store interface {
+	UserProfiles() UserProfileRepository
+	AuditEvents() AuditRepository
+	// RunInTx 在事务中执行fn，fn通过tx访问的仓库都在同一个事务中
+	// fn返回错误时回滚；dryRun为true时即使成功也回滚，不提交任何修改
+	RunInTx(ctx context.Context, dryRun bool, fn func(tx Store) error) error
+}
+
+// gormStore 基于GORM的Store，MySQL和SQLite共用
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore 使用数据库连接创建Store
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) UserProfiles() UserProfileRepository {
+	return &userProfileRepository{db: s.db}
+}
+
+func (s *gormStore) AuditEvents() AuditRepository {
+	return &auditRepository{db: s.db}
+}
+
+// errDryRunRollback 用于在DryRun时让事务回滚
+var errDryRunRollback = errors.New("dry run rollback")
+
+func (s *gormStore) RunInTx(ctx context.Context, dryRun bool, fn func(tx Store) error) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fn(&gormStore{db: tx}); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRunRollback) {
+		return nil
+	}
+	return err
+}
+
+// translateError 把GORM的错误转换为仓库接口约定的错误
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -1,55 +1,69 @@
 package db
 
 import (
-	"beast-royale-backend/internal/dao"
+	"context"
+	"errors"
 	"strings"
+
+	"beast-royale-backend/internal/dao"
+
+	"gorm.io/gorm"
 )
 
-// GetUserProfileByAddress 根据地址获取用户档案
-func GetUserProfileByAddress(address string) (*dao.UserProfile, error) {
+// userProfileRepository 基于GORM的用户档案仓库
+type userProfileRepository struct {
+	db *gorm.DB
+}
+
+// GetByAddress 根据地址获取用户档案
+func (r *userProfileRepository) GetByAddress(ctx context.Context, address string) (*dao.UserProfile, error) {
 	var profile dao.UserProfile
-	err := GetDB().Where("address = ?", strings.ToLower(address)).First(&profile).Error
+	err := r.db.WithContext(ctx).Where("address = ?", strings.ToLower(address)).First(&profile).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &profile, nil
 }
 
-// CreateUserProfile 创建用户档案
-func CreateUserProfile(profile *dao.UserProfile) error {
-	return GetDB().Create(profile).Error
+// GetByUsername 根据用户名获取用户档案
+func (r *userProfileRepository) GetByUsername(ctx context.Context, username string) (*dao.UserProfile, error) {
+	var profile dao.UserProfile
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&profile).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &profile, nil
 }
 
-// UpdateUserProfile 更新用户档案
-func UpdateUserProfile(profile *dao.UserProfile) error {
-	return GetDB().Save(profile).Error
+// Create 创建用户档案
+func (r *userProfileRepository) Create(ctx context.Context, profile *dao.UserProfile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
 }
 
-// DeleteUserProfile 删除用户档案（软删除）
-func DeleteUserProfile(address string) error {
-	return GetDB().Where("address = ?", strings.ToLower(address)).Delete(&dao.UserProfile{}).Error
+// Update 更新用户档案
+func (r *userProfileRepository) Update(ctx context.Context, profile *dao.UserProfile) error {
+	return r.db.WithContext(ctx).Save(profile).Error
 }
 
-// GetUserProfileByUsername 根据用户名获取用户档案
-func GetUserProfileByUsername(username string) (*dao.UserProfile, error) {
-	var profile dao.UserProfile
-	err := GetDB().Where("username = ?", username).First(&profile).Error
-	if err != nil {
-		return nil, err
-	}
-	return &profile, nil
+// Delete 删除用户档案
+func (r *userProfileRepository) Delete(ctx context.Context, address string) error {
+	return r.db.WithContext(ctx).Where("address = ?", strings.ToLower(address)).Delete(&dao.UserProfile{}).Error
 }
 
 // EnsureUserProfileExists 确保用户档案存在，如果不存在则创建
-func EnsureUserProfileExists(address string) error {
+// 返回新创建的档案，档案已存在时返回nil
+func EnsureUserProfileExists(ctx context.Context, profiles UserProfileRepository, address string) (*dao.UserProfile, error) {
 	// 将地址转换为小写
 	lowerAddress := strings.ToLower(address)
 
 	// 检查用户档案是否已存在
-	profile, err := GetUserProfileByAddress(lowerAddress)
-	if err == nil && profile != nil {
+	_, err := profiles.GetByAddress(ctx, lowerAddress)
+	if err == nil {
 		// 用户档案已存在
-		return nil
+		return nil, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
 	}
 
 	// 用户档案不存在，创建基础档案
@@ -60,5 +74,8 @@ func EnsureUserProfileExists(address string) error {
 		Tokens:   1000,         // 默认代币为1000
 	}
 
-	return CreateUserProfile(basicProfile)
+	if err := profiles.Create(ctx, basicProfile); err != nil {
+		return nil, err
+	}
+	return basicProfile, nil
 }
@@ -0,0 +1,117 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/internal/redispool"
+)
+
+// TargetKind 事件投递目标类型
+type TargetKind string
+
+const (
+	TargetUser  TargetKind = "user"  // 某个钱包地址的所有连接
+	TargetRoom  TargetKind = "room"  // 订阅了房间主题的连接
+	TargetGuild TargetKind = "guild" // 订阅了公会主题的连接
+)
+
+// Target 事件投递目标
+type Target struct {
+	Kind TargetKind `json:"Kind"`
+	ID   string     `json:"ID"`
+}
+
+// User 投递给某个地址
+func User(address string) Target {
+	return Target{Kind: TargetUser, ID: address}
+}
+
+// Room 投递给某个房间
+func Room(id string) Target {
+	return Target{Kind: TargetRoom, ID: id}
+}
+
+// Guild 投递给某个公会
+func Guild(id string) Target {
+	return Target{Kind: TargetGuild, ID: id}
+}
+
+// Topic 房间、公会在push.Hub中对应的主题名，客户端按该名称订阅
+func (t Target) Topic() string {
+	return string(t.Kind) + ":" + t.ID
+}
+
+// Envelope 在实例间传输的事件
+type Envelope struct {
+	Origin string      `json:"Origin"` // 发布事件的实例ID
+	Target Target      `json:"Target"`
+	Event  *push.Event `json:"Event"`
+}
+
+// Bus 实时事件总线，把事件投递到持有目标连接的实例上
+type Bus interface {
+	// Publish 发布事件，目标用户不在线时事件被丢弃
+	Publish(ctx context.Context, target Target, event *push.Event) error
+	// Nodes 返回当前持有该地址连接的实例ID
+	Nodes(ctx context.Context, address string) ([]string, error)
+	// Close 停止接收事件并清理本实例的在线状态
+	Close() error
+}
+
+// Default 进程内默认的事件总线，Init之前使用单实例实现
+var Default Bus = NewMemoryBus(push.DefaultHub)
+
+// Init 根据配置初始化事件总线
+func Init(cfg *config.Config, hub *push.Hub) error {
+	switch cfg.EventBus.Backend {
+	case "memory":
+		Default = NewMemoryBus(hub)
+	case "redis":
+		pool := redispool.GetPool()
+		if pool == nil {
+			return fmt.Errorf("redis pool is not initialized")
+		}
+		bus, err := NewRedisBus(pool, hub, NodeID(&cfg.EventBus), &cfg.EventBus)
+		if err != nil {
+			return err
+		}
+		Default = bus
+	default:
+		return fmt.Errorf("unknown event bus backend: %s", cfg.EventBus.Backend)
+	}
+	return nil
+}
+
+// Publish 通过默认事件总线发布事件
+func Publish(ctx context.Context, target Target, event *push.Event) error {
+	return Default.Publish(ctx, target, event)
+}
+
+// Close 关闭默认事件总线
+func Close() error {
+	return Default.Close()
+}
+
+// NodeID 获取本实例ID
+func NodeID(cfg *config.EventBusConfig) string {
+	if cfg.NodeID != "" {
+		return cfg.NodeID
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// deliverLocal 把事件投递给本实例上的连接
+func deliverLocal(hub *push.Hub, target Target, event *push.Event) int {
+	if target.Kind == TargetUser {
+		return hub.PushToAddress(target.ID, event)
+	}
+	return hub.PushToTopic(target.Topic(), event)
+}
@@ -0,0 +1,42 @@
+package eventbus
+
+import (
+	"context"
+	"strings"
+
+	"beast-royale-backend/internal/push"
+)
+
+// localNodeID 单实例模式下的实例ID
+const localNodeID = "local"
+
+// MemoryBus 单实例事件总线，直接投递到本进程的push.Hub
+type MemoryBus struct {
+	hub *push.Hub
+}
+
+// NewMemoryBus 创建单实例事件总线
+func NewMemoryBus(hub *push.Hub) *MemoryBus {
+	return &MemoryBus{hub: hub}
+}
+
+// Publish 实现Bus
+func (b *MemoryBus) Publish(ctx context.Context, target Target, event *push.Event) error {
+	deliverLocal(b.hub, target, event)
+	return nil
+}
+
+// Nodes 实现Bus
+func (b *MemoryBus) Nodes(ctx context.Context, address string) ([]string, error) {
+	for _, a := range b.hub.Addresses() {
+		if a == strings.ToLower(address) {
+			return []string{localNodeID}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Close 实现Bus
+func (b *MemoryBus) Close() error {
+	return nil
+}
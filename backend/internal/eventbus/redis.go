@@ -0,0 +1,308 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/internal/tracing"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisBus 基于Redis pub/sub的多实例事件总线
+//
+// 每个实例订阅自己的节点频道和一个广播频道：
+//   - 用户事件先查在线状态，只发布到持有该地址连接的实例的节点频道
+//   - 房间、公会事件发布到广播频道，由各实例投递给本地订阅了对应主题的连接
+//
+// 在线状态保存在有序集合 <channel>:presence:<address> 中，成员为实例ID，分数为过期时间戳，
+// 实例按TTL的1/3周期续期，实例异常退出后其在线状态会自然过期
+type RedisBus struct {
+	pool   *redis.Pool
+	hub    *push.Hub
+	nodeID string
+	prefix string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	psc    *redis.PubSubConn
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewRedisBus 创建Redis事件总线并开始接收事件
+func NewRedisBus(pool *redis.Pool, hub *push.Hub, nodeID string, cfg *config.EventBusConfig) (*RedisBus, error) {
+	b := &RedisBus{
+		pool:   pool,
+		hub:    hub,
+		nodeID: nodeID,
+		prefix: cfg.Channel,
+		ttl:    time.Duration(cfg.PresenceTTL) * time.Second,
+		done:   make(chan struct{}),
+	}
+
+	// 启动时先确认Redis可用
+	conn := pool.Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	hub.SetPresenceListener(b.onPresenceChange)
+
+	b.wg.Add(2)
+	go b.receiveLoop()
+	go b.presenceLoop()
+
+	logger.Info("Redis事件总线已启动 - Node: %s", nodeID)
+	return b, nil
+}
+
+// Publish 实现Bus
+func (b *RedisBus) Publish(ctx context.Context, target Target, event *push.Event) (err error) {
+	// 查询在线节点和批量PUBLISH都在该span下
+	ctx, span := tracing.Start(ctx, "eventbus.publish", trace.WithAttributes(attribute.String("beast.event", event.Event)))
+	defer func() { tracing.End(span, err) }()
+
+	payload, err := json.Marshal(&Envelope{
+		Origin: b.nodeID,
+		Target: target,
+		Event:  event,
+	})
+	if err != nil {
+		return err
+	}
+
+	channels := []string{b.broadcastChannel()}
+	if target.Kind == TargetUser {
+		nodes, err := b.Nodes(ctx, target.ID)
+		if err != nil {
+			return err
+		}
+		channels = channels[:0]
+		for _, node := range nodes {
+			channels = append(channels, b.nodeChannel(node))
+		}
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, channel := range channels {
+		if err := conn.Send("PUBLISH", channel, payload); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for range channels {
+		if _, err := conn.Receive(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Nodes 实现Bus
+func (b *RedisBus) Nodes(ctx context.Context, address string) ([]string, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn = tracing.RedisConn(ctx, conn)
+
+	key := b.presenceKey(address)
+	now := time.Now().UnixMilli()
+	if _, err := conn.Do("ZREMRANGEBYSCORE", key, "-inf", now); err != nil {
+		return nil, err
+	}
+	return redis.Strings(conn.Do("ZRANGE", key, 0, -1))
+}
+
+// Close 实现Bus
+func (b *RedisBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	if b.psc != nil {
+		b.psc.Unsubscribe()
+		b.psc.Conn.Close()
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+
+	// 清理本实例的在线状态，避免其他实例继续向这里发布事件
+	conn := b.pool.Get()
+	defer conn.Close()
+	for _, address := range b.hub.Addresses() {
+		conn.Send("ZREM", b.presenceKey(address), b.nodeID)
+	}
+	return conn.Flush()
+}
+
+// receiveLoop 订阅节点频道和广播频道，连接断开后自动重连
+func (b *RedisBus) receiveLoop() {
+	defer b.wg.Done()
+
+	for {
+		if err := b.receive(); err != nil {
+			logger.Error("事件总线订阅中断 - Node: %s, Error: %v", b.nodeID, err)
+		}
+
+		select {
+		case <-b.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (b *RedisBus) receive() error {
+	// 订阅连接长期占用，不从连接池中获取
+	conn, err := b.pool.Dial()
+	if err != nil {
+		return err
+	}
+
+	psc := &redis.PubSubConn{Conn: conn}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		conn.Close()
+		return nil
+	}
+	b.psc = psc
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.psc = nil
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	if err := psc.Subscribe(b.nodeChannel(b.nodeID), b.broadcastChannel()); err != nil {
+		return err
+	}
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			b.handleMessage(v.Data)
+		case redis.Subscription:
+			if v.Count == 0 {
+				return nil
+			}
+		case error:
+			select {
+			case <-b.done:
+				return nil
+			default:
+				return v
+			}
+		}
+	}
+}
+
+func (b *RedisBus) handleMessage(data []byte) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Event == nil {
+		logger.Error("事件总线消息格式错误 - Node: %s, Error: %v", b.nodeID, err)
+		return
+	}
+	deliverLocal(b.hub, envelope.Target, envelope.Event)
+}
+
+// presenceLoop 周期性续期本实例上所有在线地址的状态
+func (b *RedisBus) presenceLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if err := b.refreshPresence(b.hub.Addresses()...); err != nil {
+				logger.Error("续期在线状态失败 - Node: %s, Error: %v", b.nodeID, err)
+			}
+		}
+	}
+}
+
+// onPresenceChange 地址在本实例上线/下线时立即更新在线状态
+func (b *RedisBus) onPresenceChange(address string, online bool) {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return
+	}
+
+	var err error
+	if online {
+		err = b.refreshPresence(address)
+	} else {
+		conn := b.pool.Get()
+		_, err = conn.Do("ZREM", b.presenceKey(address), b.nodeID)
+		conn.Close()
+	}
+	if err != nil {
+		logger.Error("更新在线状态失败 - Node: %s, Address: %s, Error: %v", b.nodeID, address, err)
+	}
+}
+
+func (b *RedisBus) refreshPresence(addresses ...string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	conn := b.pool.Get()
+	defer conn.Close()
+
+	expireAt := time.Now().Add(b.ttl).UnixMilli()
+	for _, address := range addresses {
+		key := b.presenceKey(address)
+		conn.Send("ZADD", key, expireAt, b.nodeID)
+		conn.Send("PEXPIRE", key, b.ttl.Milliseconds())
+	}
+	_, err := conn.Do("")
+	return err
+}
+
+func (b *RedisBus) nodeChannel(nodeID string) string {
+	return b.prefix + ":node:" + nodeID
+}
+
+func (b *RedisBus) broadcastChannel() string {
+	return b.prefix + ":broadcast"
+}
+
+func (b *RedisBus) presenceKey(address string) string {
+	return b.prefix + ":presence:" + strings.ToLower(address)
+}
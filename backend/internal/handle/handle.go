@@ -1,78 +1,190 @@
 package handle
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+	"beast-royale-backend/internal/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Handle 统一处理器
-func Handle(c *gin.Context) {
+// Handle 统一处理器，HTTP状态码只反映传输层错误，业务结果由RetCode表示
+func Handle(deps *api.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handle(c, deps, func(api.Response) int { return http.StatusOK })
+	}
+}
+
+// HandleRoute REST路由处理器，HTTP状态码由RetCode映射
+func HandleRoute(route api.Route, deps *api.Deps) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handle(c, deps, func(response api.Response) int {
+			if status, ok := route.StatusMap[response.GetRetCode()]; ok {
+				return status
+			}
+			return StatusFromRetCode(response.GetRetCode())
+		})
+	}
+}
+
+func handle(c *gin.Context, deps *api.Deps, status func(api.Response) int) {
 	// 从PreJobMiddleware获取已解析的数据
 	action := c.GetString("action")
 	if action == "" {
-		logger.Error("Action not found in context")
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "Action not found"))
+		logger.ErrorContext(c, "Action not found in context")
+		codec.Render(c, http.StatusBadRequest, api.MakeErrorResponse(400, "Action not found"))
 		return
 	}
 
 	_params, exists := c.Get("params")
 	if !exists {
-		logger.Error("Params not found in context")
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "Params not found"))
+		logger.ErrorContext(c, "Params not found in context")
+		codec.Render(c, http.StatusBadRequest, api.MakeErrorResponse(400, "Params not found"))
 		return
 	}
 
 	requestData, ok := _params.(*map[string]interface{})
 	if !ok {
-		logger.Error("Params type assertion failed")
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "Invalid params format"))
+		logger.ErrorContext(c, "Params type assertion failed")
+		codec.Render(c, http.StatusBadRequest, api.MakeErrorResponse(400, "Invalid params format"))
 		return
 	}
 
 	// 获取RequestUUID
 	reqUUID := c.GetString("RequestUUID")
 	if reqUUID == "" {
-		logger.Error("RequestUUID not found in context")
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "RequestUUID not found"))
+		logger.ErrorContext(c, "RequestUUID not found in context")
+		codec.Render(c, http.StatusBadRequest, api.MakeErrorResponse(400, "RequestUUID not found"))
+		return
+	}
+
+	response, err := Dispatch(c, deps, action, requestData)
+	if err != nil {
+		codec.Render(c, StatusFromError(err), response)
 		return
 	}
 
-	// 记录请求日志
-	logger.Info("收到请求 - Action: %s, UUID: %s, Client: %s",
-		action, reqUUID, c.ClientIP())
+	// 返回响应
+	codec.Render(c, status(response), response)
+}
+
+var (
+	ErrUnknownAction = errors.New("unknown action")
+	ErrInvalidParams = errors.New("invalid params")
+	ErrTaskFailed    = errors.New("task execution failed")
+)
+
+// Dispatch 创建并执行Action对应的任务，/api、/rpc等各种传输方式共用，deps注入创建的任务
+// 失败时返回错误响应和对应的错误（ErrUnknownAction、ErrInvalidParams、ErrTaskFailed）
+func Dispatch(c *gin.Context, deps *api.Deps, action string, requestData *map[string]interface{}) (api.Response, error) {
+	start := time.Now()
+	response, err := dispatch(c, deps, action, requestData)
+
+	// 未注册的Action统一计入unknown，避免任意Action名称产生大量指标
+	label := action
+	if errors.Is(err, ErrUnknownAction) {
+		label = "unknown"
+	}
+	retCode := 0
+	if response != nil {
+		retCode = response.GetRetCode()
+	}
+	metrics.ObserveAction(label, retCode, time.Since(start))
+	return response, err
+}
+
+func dispatch(c *gin.Context, deps *api.Deps, action string, requestData *map[string]interface{}) (api.Response, error) {
+	// 记录请求日志，RequestUUID等请求字段由logger从context中附加
+	// 参数中的签名等敏感字段，以及请求结构体中带有 log:"sensitive" tag的参数会被脱敏
+	logger.DebugContext(c, "收到请求 - Action: %s, Client: %s", action, c.ClientIP())
+	if logger.Logger().Enabled(c, slog.LevelDebug) {
+		logger.DebugContext(c, "请求参数 - Action: %s, Params: %s", action,
+			logger.Redacted(logger.RedactMap(*requestData, api.SensitiveParams(action)...)))
+	}
 
 	// 检查Action是否存在
 	if !api.Exist(action) {
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "Unknown action: "+action))
-		return
+		return api.MakeErrorResponse(400, "Unknown action: "+action), ErrUnknownAction
+	}
+
+	// DryRun的类型不对时拒绝请求，不能当作普通请求执行
+	dryRun, err := api.IsDryRun(requestData)
+	if err != nil {
+		return api.MakeErrorResponse(400, err.Error()), fmt.Errorf("%w: %v", ErrInvalidParams, err)
 	}
 
 	// 创建任务
-	task, err := api.NewTask(action, requestData)
+	task, err := api.NewTask(action, requestData, deps)
 	if err != nil {
-		logger.Error("创建任务失败: %v", err)
-		c.JSON(http.StatusBadRequest, api.MakeErrorResponse(400, "Failed to create task: "+err.Error()))
-		return
+		logger.ErrorContext(c, "创建任务失败: %v", err)
+		return api.MakeErrorResponse(400, "Failed to create task: "+err.Error()), fmt.Errorf("%w: %v", ErrInvalidParams, err)
 	}
 
-	// 执行任务
-	response, err := task.Run(c)
+	// 执行任务，DryRun请求只能交给实现了DryRunner的任务
+	run := task.Run
+	if dryRun {
+		dryRunner, ok := task.(api.DryRunner)
+		if !ok {
+			return api.MakeErrorResponse(400, "DryRun is not supported by action: "+action), fmt.Errorf("%w: dry run not supported", ErrInvalidParams)
+		}
+		logger.InfoContext(c, "DryRun请求 - Action: %s", action)
+		run = dryRunner.DryRun
+	}
+	response, err := runTask(c, action, run)
 	if err != nil {
-		logger.Error("执行任务失败: %v", err)
-		c.JSON(http.StatusInternalServerError, api.MakeErrorResponse(500, "Task execution failed: "+err.Error()))
-		return
+		logger.ErrorContext(c, "执行任务失败: %v", err)
+		return api.MakeErrorResponse(500, "Task execution failed: "+err.Error()), fmt.Errorf("%w: %v", ErrTaskFailed, err)
 	}
 
-	// 记录响应日志
-	resJson, _ := json.Marshal(response)
-	logger.Info("发送响应 - Action: %s, UUID: %s, Client: %s, Response: %s",
-		action, response.GetRequestUUID(), c.ClientIP(), string(resJson))
+	// 记录响应日志，响应内容只在debug级别输出，带有 log:"sensitive" tag的字段会被脱敏
+	logger.InfoContext(c, "发送响应 - Action: %s, RetCode: %d", action, response.GetRetCode())
+	if logger.Logger().Enabled(c, slog.LevelDebug) {
+		logger.DebugContext(c, "响应内容 - Action: %s, Response: %s", action, logger.Redacted(response))
+	}
 
-	// 返回响应
-	c.JSON(http.StatusOK, response)
+	return response, nil
+}
+
+// runTask 在Task的span下执行任务，任务中的数据库和Redis调用都挂在该span下
+func runTask(c *gin.Context, action string, run func(c *gin.Context) (api.Response, error)) (api.Response, error) {
+	request := c.Request
+	ctx, span := tracing.Start(request.Context(), "task."+action, trace.WithAttributes(attribute.String("beast.action", action)))
+	c.Request = request.WithContext(ctx)
+	defer func() {
+		// 同一个请求可能执行多个Action（例如JSON-RPC批量调用），结束后恢复原来的context
+		c.Request = request
+	}()
+
+	response, err := run(c)
+	if err == nil && response != nil {
+		span.SetAttributes(attribute.Int("beast.ret_code", response.GetRetCode()))
+	}
+	tracing.End(span, err)
+	return response, err
+}
+
+// StatusFromError 将Dispatch返回的错误转换为HTTP状态码
+func StatusFromError(err error) int {
+	if errors.Is(err, ErrTaskFailed) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// StatusFromRetCode 将RetCode映射为HTTP状态码，4xx/5xx原样使用，其余（包括206部分成功）视为成功
+func StatusFromRetCode(retCode int) int {
+	if retCode >= 400 && retCode < 600 {
+		return retCode
+	}
+	return http.StatusOK
 }
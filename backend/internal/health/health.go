@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/version"
+)
+
+// 健康状态
+const (
+	STATUS_OK            = "ok"
+	STATUS_DOWN          = "down"
+	STATUS_SHUTTING_DOWN = "shutting_down"
+)
+
+// DEFAULT_PROBE_TIMEOUT 单个依赖探测的默认超时时间
+const DEFAULT_PROBE_TIMEOUT = 2 * time.Second
+
+// Probe 探测一个依赖是否可用，需要在ctx超时前返回
+type Probe func(ctx context.Context) error
+
+// ComponentStatus 单个依赖的探测结果
+// 就绪检查不需要认证，错误信息可能包含连接地址等内部信息，只写入日志，不返回给调用方
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Report 健康检查结果
+type Report struct {
+	Status     string                     `json:"status"`
+	Version    string                     `json:"version"`
+	Commit     string                     `json:"commit"`
+	BuildTime  string                     `json:"build_time,omitempty"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// Ready 是否可以接收流量
+func (r *Report) Ready() bool {
+	return r.Status == STATUS_OK
+}
+
+// Checker 管理依赖探测和服务的关闭状态
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	probes map[string]Probe
+
+	shuttingDown atomic.Bool
+}
+
+// DefaultChecker 服务使用的Checker，依赖在初始化成功后注册探测
+var DefaultChecker = NewChecker(DEFAULT_PROBE_TIMEOUT)
+
+// NewChecker 创建Checker，timeout为单个探测的超时时间
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		probes:  make(map[string]Probe),
+	}
+}
+
+// AddProbe 注册依赖探测，同名时覆盖
+func (c *Checker) AddProbe(name string, probe Probe) {
+	c.mu.Lock()
+	c.probes[name] = probe
+	c.mu.Unlock()
+}
+
+// SetShuttingDown 标记服务正在关闭，之后的就绪检查都返回未就绪，让负载均衡先摘除流量
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// ShuttingDown 服务是否正在关闭
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Live 存活检查，只反映进程本身，不探测依赖
+func (c *Checker) Live() *Report {
+	return newReport(STATUS_OK)
+}
+
+// Ready 就绪检查，并发探测所有依赖，任一依赖不可用或服务正在关闭时未就绪
+func (c *Checker) Ready(ctx context.Context) *Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.probes))
+	for name := range c.probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	probes := make([]Probe, len(names))
+	for i, name := range names {
+		probes[i] = c.probes[name]
+	}
+	c.mu.RUnlock()
+
+	results := make([]ComponentStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.probe(ctx, names[i], probes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := newReport(STATUS_OK)
+	report.Components = make(map[string]ComponentStatus, len(names))
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status != STATUS_OK {
+			report.Status = STATUS_DOWN
+		}
+	}
+	if c.ShuttingDown() {
+		report.Status = STATUS_SHUTTING_DOWN
+	}
+	return report
+}
+
+func (c *Checker) probe(ctx context.Context, name string, probe Probe) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe(ctx)
+	// 探测没有遵守ctx时以超时为准
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	status := ComponentStatus{
+		Status:    STATUS_OK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = STATUS_DOWN
+		logger.WarnContext(ctx, "依赖探测失败 - Component: %s, Error: %v", name, err)
+	}
+	return status
+}
+
+func newReport(status string) *Report {
+	return &Report{
+		Status:    status,
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildTime: version.BuildTime,
+	}
+}
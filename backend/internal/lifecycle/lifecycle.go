@@ -0,0 +1,91 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"beast-royale-backend/internal/logger"
+)
+
+// DEFAULT_STOP_TIMEOUT 组件未指定超时时间时的默认关闭超时
+const DEFAULT_STOP_TIMEOUT = 5 * time.Second
+
+// StopFunc 关闭一个组件，需要在ctx超时前返回
+type StopFunc func(ctx context.Context) error
+
+type hook struct {
+	name    string
+	timeout time.Duration
+	stop    StopFunc
+}
+
+// Manager 管理服务中各组件的关闭顺序
+// 组件在初始化成功后注册，关闭时按注册的相反顺序执行：后启动的先关闭，
+// 例如先停止HTTP服务、再关闭事件总线，最后关闭Redis和数据库连接池
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []hook
+	stopped bool
+}
+
+// NewManager 创建Manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// OnStop 注册组件的关闭函数，timeout为该组件关闭的最长时间，<=0时使用DEFAULT_STOP_TIMEOUT
+func (m *Manager) OnStop(name string, timeout time.Duration, stop StopFunc) {
+	if timeout <= 0 {
+		timeout = DEFAULT_STOP_TIMEOUT
+	}
+	m.mu.Lock()
+	m.hooks = append(m.hooks, hook{name: name, timeout: timeout, stop: stop})
+	m.mu.Unlock()
+}
+
+// Stop 按注册的相反顺序关闭所有组件，只执行一次
+// 某个组件关闭失败或超时不影响后面的组件，所有错误合并后返回
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		start := time.Now()
+		err := runHook(h)
+		if err != nil {
+			logger.Error("关闭%s失败 - Duration: %v, Error: %v", h.name, time.Since(start), err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.name, err))
+			continue
+		}
+		logger.Info("%s已关闭 - Duration: %v", h.name, time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// runHook 执行关闭函数，关闭函数没有遵守ctx时也在超时后返回
+func runHook(h hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
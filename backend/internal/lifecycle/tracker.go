@@ -0,0 +1,45 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker 记录正在处理的请求，关闭服务时等待它们完成
+type Tracker struct {
+	wg    sync.WaitGroup
+	count atomic.Int64
+}
+
+// Add 开始处理一个请求
+func (t *Tracker) Add() {
+	t.count.Add(1)
+	t.wg.Add(1)
+}
+
+// Done 一个请求处理完成
+func (t *Tracker) Done() {
+	t.count.Add(-1)
+	t.wg.Done()
+}
+
+// Count 正在处理的请求数
+func (t *Tracker) Count() int64 {
+	return t.count.Load()
+}
+
+// Wait 等待所有请求处理完成，ctx超时时返回ctx的错误
+func (t *Tracker) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestFields 自动附加到日志中的请求字段：context中的key -> 日志字段名
+// gin.Context 的 Value 会读取 c.Set 设置的值，因此传入 *gin.Context 即可自动带上这些字段
+var requestFields = []struct {
+	key  string
+	name string
+}{
+	{"RequestID", "RequestID"},
+	{"RequestUUID", "RequestUUID"},
+	{"action", "Action"},
+	{"address", "Address"},
+}
+
+// contextHandler 从context中提取请求字段附加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func newContextHandler(h slog.Handler) slog.Handler {
+	return &contextHandler{Handler: h}
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, field := range requestFields {
+		if v, ok := ctx.Value(field.key).(string); ok && v != "" {
+			r.AddAttrs(slog.String(field.name, v))
+		}
+	}
+	// 带上trace ID，便于从日志跳转到链路
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("TraceID", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -1,67 +1,213 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"beast-royale-backend/internal/config"
+)
+
+// 日志输出格式
+const (
+	TEXT_FORMAT = "text"
+	JSON_FORMAT = "json"
 )
 
-var (
-	InfoLogger  *log.Logger
-	ErrorLogger *log.Logger
-	DebugLogger *log.Logger
+// 日志输出目标
+const (
+	STDOUT_OUTPUT = "stdout"
+	FILE_OUTPUT   = "file"
+	BOTH_OUTPUT   = "both"
 )
 
-// Init 初始化日志系统
-func Init() error {
-	// 创建logs目录
-	logsDir := "bin/logs"
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
+// 跟踪日志类别
+const (
+	SIGNATURE_TRACE = "signature" // 钱包签名验证的完整过程
+)
+
+// traces 配置中开启的跟踪日志类别，重新加载配置时整体替换
+var traces atomic.Pointer[map[string]bool]
+
+// level 当前的日志级别，重新加载配置时修改，不需要重建handler
+var level = new(slog.LevelVar)
+
+// defaultLogger 当前的logger，未调用Init之前输出到stdout，级别为info
+// Init可能在其他goroutine仍在输出日志时调用（例如测试中依次启动的服务），因此整体原子替换
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	defaultLogger.Store(slog.New(newContextHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{AddSource: true}))))
+}
+
+// Init 按配置初始化日志系统
+func Init(cfg config.LoggingConfig) error {
+	if _, err := parseLevel(cfg.Level); err != nil {
 		return err
 	}
 
-	// 创建日志文件
-	currentTime := time.Now().Format("2006-01-02")
-	logFile, err := os.OpenFile(
-		filepath.Join(logsDir, "app-"+currentTime+".log"),
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-		0644,
-	)
-	if err != nil {
+	var w io.Writer
+	switch strings.ToLower(cfg.Output) {
+	case "", STDOUT_OUTPUT:
+		w = os.Stdout
+	case FILE_OUTPUT, BOTH_OUTPUT:
+		fileWriter, err := newRotateWriter(cfg.Dir, "app", "log", cfg.MaxAge, cfg.RotationTime)
+		if err != nil {
+			return fmt.Errorf("创建日志文件失败: %w", err)
+		}
+		w = fileWriter
+		if strings.ToLower(cfg.Output) == BOTH_OUTPUT {
+			w = io.MultiWriter(fileWriter, os.Stdout)
+		}
+	default:
+		return fmt.Errorf("不支持的日志输出: %s", cfg.Output)
+	}
+	return InitWriter(cfg, w)
+}
+
+// InitWriter 按配置的格式和级别把日志输出到w，忽略output和dir，用于测试中检查日志内容
+func InitWriter(cfg config.LoggingConfig, w io.Writer) error {
+	if _, err := parseLevel(cfg.Level); err != nil {
 		return err
 	}
 
-	// 创建多输出writer（同时输出到文件和控制台）
-	multiWriter := io.MultiWriter(logFile, os.Stdout)
-	errorWriter := io.MultiWriter(logFile, os.Stderr)
+	opts := &slog.HandlerOptions{Level: level, AddSource: true}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", TEXT_FORMAT:
+		handler = slog.NewTextHandler(w, opts)
+	case JSON_FORMAT:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", cfg.Format)
+	}
+
+	defaultLogger.Store(slog.New(newContextHandler(handler)))
+	return Reload(cfg)
+}
+
+// Reload 更新日志级别、跟踪类别和长度限制，输出位置和格式修改后需要重启
+func Reload(cfg config.LoggingConfig) error {
+	lvl, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
 
-	// 初始化不同类型的日志记录器
-	InfoLogger = log.New(multiWriter, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLogger = log.New(errorWriter, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	DebugLogger = log.New(multiWriter, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+	fieldSize, bodySize := DEFAULT_MAX_FIELD_SIZE, DEFAULT_MAX_BODY_SIZE
+	if cfg.MaxFieldSize > 0 {
+		fieldSize = cfg.MaxFieldSize
+	}
+	if cfg.MaxBodySize > 0 {
+		bodySize = cfg.MaxBodySize
+	}
+	maxFieldSize.Store(int64(fieldSize))
+	maxBodySize.Store(int64(bodySize))
 
+	enabled := make(map[string]bool, len(cfg.Trace))
+	for _, category := range cfg.Trace {
+		enabled[strings.ToLower(category)] = true
+	}
+	traces.Store(&enabled)
 	return nil
 }
 
-// Info 记录信息日志
-func Info(format string, v ...interface{}) {
-	if InfoLogger != nil {
-		InfoLogger.Printf(format, v...)
+// Logger 返回底层的slog.Logger，用于需要结构化字段的场景
+func Logger() *slog.Logger {
+	return defaultLogger.Load()
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
 	}
+	return slog.LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
 }
 
-// Error 记录错误日志
-func Error(format string, v ...interface{}) {
-	if ErrorLogger != nil {
-		ErrorLogger.Printf(format, v...)
+// logf 格式化并输出日志，记录调用方的源码位置
+func logf(ctx context.Context, level slog.Level, format string, v ...interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	if !defaultLogger.Load().Enabled(ctx, level) {
+		return
+	}
+	// 跳过 runtime.Callers、output、logf 和导出的日志函数
+	output(ctx, 4, level, format, v...)
+}
+
+// output 直接交给handler输出，不再检查日志级别，skip为到调用方的栈帧数
+func output(ctx context.Context, skip int, level slog.Level, format string, v ...interface{}) {
+	var pcs [1]uintptr
+	runtime.Callers(skip, pcs[:])
+	r := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, v...), pcs[0])
+	_ = defaultLogger.Load().Handler().Handle(ctx, r)
 }
 
 // Debug 记录调试日志
 func Debug(format string, v ...interface{}) {
-	if DebugLogger != nil {
-		DebugLogger.Printf(format, v...)
+	logf(context.Background(), slog.LevelDebug, format, v...)
+}
+
+// Info 记录信息日志
+func Info(format string, v ...interface{}) {
+	logf(context.Background(), slog.LevelInfo, format, v...)
+}
+
+// Warn 记录警告日志
+func Warn(format string, v ...interface{}) {
+	logf(context.Background(), slog.LevelWarn, format, v...)
+}
+
+// Error 记录错误日志
+func Error(format string, v ...interface{}) {
+	logf(context.Background(), slog.LevelError, format, v...)
+}
+
+// DebugContext 记录调试日志，并附带ctx中的请求字段
+func DebugContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelDebug, format, v...)
+}
+
+// InfoContext 记录信息日志，并附带ctx中的请求字段
+func InfoContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelInfo, format, v...)
+}
+
+// WarnContext 记录警告日志，并附带ctx中的请求字段
+func WarnContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelWarn, format, v...)
+}
+
+// ErrorContext 记录错误日志，并附带ctx中的请求字段
+func ErrorContext(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, slog.LevelError, format, v...)
+}
+
+// TraceEnabled 是否开启了某类跟踪日志
+func TraceEnabled(category string) bool {
+	enabled := traces.Load()
+	return enabled != nil && (*enabled)[category]
+}
+
+// Trace 输出跟踪日志，只在配置的trace中包含该类别时输出，不受日志级别限制
+// 跟踪日志可能包含签名等原始数据，只应在排查问题时临时开启
+func Trace(category string, format string, v ...interface{}) {
+	if !TraceEnabled(category) {
+		return
 	}
+	output(context.Background(), 3, slog.LevelDebug, "["+category+"] "+format, v...)
 }
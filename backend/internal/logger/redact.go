@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// REDACTED 敏感字段在日志中的替代值
+const REDACTED = "[REDACTED]"
+
+// 字段和日志内容的默认长度限制，可由配置覆盖
+const (
+	DEFAULT_MAX_FIELD_SIZE = 256
+	DEFAULT_MAX_BODY_SIZE  = 4096
+)
+
+// maxItems 数组最多输出的元素个数，maxDepth 最多展开的嵌套层数
+const (
+	maxItems = 20
+	maxDepth = 8
+)
+
+// maxFieldSize、maxBodySize 当前的长度限制，由Init和Reload设置
+var (
+	maxFieldSize atomic.Int64
+	maxBodySize  atomic.Int64
+)
+
+func init() {
+	maxFieldSize.Store(DEFAULT_MAX_FIELD_SIZE)
+	maxBodySize.Store(DEFAULT_MAX_BODY_SIZE)
+}
+
+// sensitiveKeys map中按key名（不区分大小写）脱敏的字段，用于请求参数等没有结构体tag的数据
+// 新的Action在请求结构体的字段上添加 `log:"sensitive"` tag即可，不需要修改这里
+var sensitiveKeys = map[string]bool{
+	"signature":  true,
+	"token":      true,
+	"password":   true,
+	"secret":     true,
+	"privatekey": true,
+	"apikey":     true,
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Redact 把v转换为可以安全输出到日志的值
+// 结构体字段使用json tag命名，带有 `log:"sensitive"` tag的字段替换为[REDACTED]，
+// map中名称属于敏感key的字段同样替换，过长的字符串和数组会被截断
+func Redact(v interface{}) interface{} {
+	return redactValue(reflect.ValueOf(v), 0)
+}
+
+// Redacted 返回脱敏后的JSON字符串，超过长度限制时截断，用于在日志中输出请求和响应
+func Redacted(v interface{}) string {
+	data, err := json.Marshal(Redact(v))
+	if err != nil {
+		return fmt.Sprintf("<marshal failed: %v>", err)
+	}
+	if limit := int(maxBodySize.Load()); len(data) > limit {
+		return string(data[:limit]) + fmt.Sprintf("...(truncated %d bytes)", len(data)-limit)
+	}
+	return string(data)
+}
+
+// RedactMap 复制m并把名称属于敏感key或keys的字段（不区分大小写）替换为[REDACTED]，嵌套的map和数组同样处理
+// keys 通常是请求结构体中带有 `log:"sensitive"` tag的参数名，见 api.SensitiveParams
+// 与Redact不同，其余内容保持原样、不截断，用于请求参数日志和录制fixture
+func RedactMap(m map[string]interface{}, keys ...string) map[string]interface{} {
+	extra := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		extra[strings.ToLower(key)] = true
+	}
+	return redactMap(m, extra)
+}
+
+func redactMap(m map[string]interface{}, extra map[string]bool) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if lower := strings.ToLower(key); sensitiveKeys[lower] || extra[lower] {
+			out[key] = REDACTED
+			continue
+		}
+		out[key] = redactJSONValue(value, extra)
+	}
+	return out
+}
+
+func redactJSONValue(v interface{}, extra map[string]bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return redactMap(value, extra)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = redactJSONValue(item, extra)
+		}
+		return out
+	}
+	return v
+}
+
+func redactValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxDepth {
+		return "..."
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), depth)
+	}
+
+	// 通过未导出字段取到的值无法Interface
+	if !v.CanInterface() {
+		return nil
+	}
+	// time.Time等自定义序列化的类型保持原样，由encoding/json输出
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		redactStruct(v, out, depth)
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if sensitiveKeys[strings.ToLower(key)] {
+				out[key] = REDACTED
+				continue
+			}
+			out[key] = redactValue(iter.Value(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("[%d bytes]", v.Len())
+		}
+		n := v.Len()
+		out := make([]interface{}, 0, min(n, maxItems)+1)
+		for i := 0; i < n && i < maxItems; i++ {
+			out = append(out, redactValue(v.Index(i), depth+1))
+		}
+		if n > maxItems {
+			out = append(out, fmt.Sprintf("...(%d more)", n-maxItems))
+		}
+		return out
+	case reflect.String:
+		return truncate(v.String())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	}
+	return v.Interface()
+}
+
+// redactStruct 展开结构体字段，匿名嵌入且没有json名称的结构体与encoding/json一样平铺
+func redactStruct(v reflect.Value, out map[string]interface{}, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				redactStruct(fv, out, depth)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		if field.Tag.Get("log") == "sensitive" {
+			out[name] = REDACTED
+			continue
+		}
+		out[name] = redactValue(fv, depth+1)
+	}
+}
+
+// truncate 截断超过长度限制的字符串，保证不截断在UTF-8字符中间
+func truncate(s string) string {
+	limit := int(maxFieldSize.Load())
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("...(truncated %d bytes)", len(s)-cut)
+}
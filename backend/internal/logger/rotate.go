@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"path"
+	"time"
+
+	rotatelogs "github.com/lestrrat/go-file-rotatelogs"
+)
+
+// newRotateWriter 按时间切割的日志文件，maxAge单位为天，rotationTime单位为小时
+func newRotateWriter(dir, prefix, suffix string, maxAge, rotationTime int) (io.Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	baseLogName := prefix + "." + suffix
+	baseLogPath := path.Join(dir, baseLogName)
+	writer, err := rotatelogs.New(
+		baseLogPath+".%Y%m%d%H%M",
+		rotatelogs.WithLinkName(baseLogPath),                               // 生成软链，指向最新日志文件
+		rotatelogs.WithMaxAge(time.Duration(maxAge)*24*time.Hour),          // 文件最大保存时间
+		rotatelogs.WithRotationTime(time.Duration(rotationTime)*time.Hour), // 日志切割时间间隔
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
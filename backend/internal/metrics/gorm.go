@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// InstrumentGORM 注册GORM回调，统计每类操作的耗时和错误
+func InstrumentGORM(db *gorm.DB) error {
+	callbacks := db.Callback()
+	type processor interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}
+	hooks := []struct {
+		operation string
+		before    processor
+		after     processor
+	}{
+		{"create", callbacks.Create().Before("gorm:create"), callbacks.Create().After("gorm:create")},
+		{"query", callbacks.Query().Before("gorm:query"), callbacks.Query().After("gorm:query")},
+		{"update", callbacks.Update().Before("gorm:update"), callbacks.Update().After("gorm:update")},
+		{"delete", callbacks.Delete().Before("gorm:delete"), callbacks.Delete().After("gorm:delete")},
+		{"row", callbacks.Row().Before("gorm:row"), callbacks.Row().After("gorm:row")},
+		{"raw", callbacks.Raw().Before("gorm:raw"), callbacks.Raw().After("gorm:raw")},
+	}
+	for _, hook := range hooks {
+		if err := hook.before.Register("metrics:before_"+hook.operation, gormBefore); err != nil {
+			return err
+		}
+		if err := hook.after.Register("metrics:after_"+hook.operation, gormAfter(hook.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gormBefore(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func gormAfter(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		dbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			dbQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "beast"
+
+// Registry 服务自己的指标注册表，不使用全局的DefaultRegisterer，避免第三方库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求数",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	actionRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "action_requests_total",
+		Help:      "Action请求数，按RetCode区分结果",
+	}, []string{"action", "ret_code"})
+
+	actionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "action_duration_seconds",
+		Help:      "Action执行耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"action"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "鉴权失败次数",
+	}, []string{"auth_type"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM数据库操作耗时",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	dbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "GORM数据库操作错误数，不包括记录不存在",
+	}, []string{"operation", "table"})
+
+	inflightRequests = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "inflight_requests",
+		Help:      "正在处理的HTTP请求数，包括WebSocket长连接",
+	})
+
+	sessionsReissued = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_reissued_total",
+		Help:      "由旧密钥签发、已用当前密钥重新签发的session cookie数，持续为0后可以删除旧密钥",
+	})
+
+	sessionStoreErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "session_store_errors_total",
+		Help:      "session存储读写错误数",
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		actionRequests,
+		actionDuration,
+		authFailures,
+		dbQueryDuration,
+		dbQueryErrors,
+		inflightRequests,
+		sessionsReissued,
+		sessionStoreErrors,
+	)
+}
+
+// Handler 输出Registry中的指标，压缩由路由上的gzip中间件处理
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry, DisableCompression: true})
+}
+
+// ObserveHTTP 记录一次HTTP请求，route使用路由模板而不是实际路径，避免标签基数过大
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// RequestStarted 开始处理一个HTTP请求
+func RequestStarted() {
+	inflightRequests.Inc()
+}
+
+// RequestFinished 一个HTTP请求处理完成
+func RequestFinished() {
+	inflightRequests.Dec()
+}
+
+// ObserveAction 记录一次Action执行
+func ObserveAction(action string, retCode int, duration time.Duration) {
+	actionRequests.WithLabelValues(action, strconv.Itoa(retCode)).Inc()
+	actionDuration.WithLabelValues(action).Observe(duration.Seconds())
+}
+
+// AuthFailed 记录一次鉴权失败
+func AuthFailed(authType string) {
+	authFailures.WithLabelValues(authType).Inc()
+}
+
+// SessionStoreError 记录一次session存储错误
+func SessionStoreError(operation string) {
+	sessionStoreErrors.WithLabelValues(operation).Inc()
+}
+
+// SessionReissued 记录一次session cookie重新签发
+func SessionReissued() {
+	sessionsReissued.Inc()
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// sessionStore 统计session存储读写错误的包装
+type sessionStore struct {
+	sessions.Store
+}
+
+// InstrumentSessionStore 包装session存储，Get/New/Save返回错误时计数
+// 注意：cookie签名无效等客户端原因导致的Get/New错误同样会被计入
+func InstrumentSessionStore(store sessions.Store) sessions.Store {
+	return &sessionStore{Store: store}
+}
+
+// Get 通过请求上的registry获取session，同一个请求内多次获取返回同一个session；registry中没有时调用New
+func (s *sessionStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	session, err := gsessions.GetRegistry(r).Get(s, name)
+	if err != nil {
+		SessionStoreError("get")
+	}
+	return session, err
+}
+
+func (s *sessionStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session, err := s.Store.New(r, name)
+	if err != nil {
+		SessionStoreError("new")
+	}
+	return s.bind(session), err
+}
+
+func (s *sessionStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	err := s.Store.Save(r, w, session)
+	if err != nil {
+		SessionStoreError("save")
+	}
+	return err
+}
+
+// bind 底层存储创建的session会在Save时直接调用底层存储，转交给包装使 session.Save() 也经过计数
+// 底层存储的New不会把session注册到registry，转交后只有绑定到包装的session被注册和使用
+func (s *sessionStore) bind(session *gsessions.Session) *gsessions.Session {
+	if session == nil {
+		return nil
+	}
+	bound := gsessions.NewSession(s, session.Name())
+	bound.ID = session.ID
+	bound.Values = session.Values
+	bound.Options = session.Options
+	bound.IsNew = session.IsNew
+	return bound
+}
@@ -0,0 +1,177 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"beast-royale-backend/internal/logger"
+)
+
+// Fixture 一次录制的请求和响应
+type Fixture struct {
+	Action   string                 `json:"Action"`
+	Scenario string                 `json:"Scenario,omitempty"` // 所属场景，为空表示默认场景
+	Match    []string               `json:"Match,omitempty"`    // 回放时除Action外需要匹配的参数名
+	Request  map[string]interface{} `json:"Request"`
+	Status   int                    `json:"Status"`
+	Response map[string]interface{} `json:"Response"`
+	// RecordedAt 录制时间，多个fixture同样匹配时使用最新的
+	RecordedAt time.Time `json:"RecordedAt"`
+}
+
+// matches 请求是否满足fixture的匹配条件
+func (f *Fixture) matches(params map[string]interface{}) bool {
+	for _, name := range f.Match {
+		if fmt.Sprint(f.Request[name]) != fmt.Sprint(params[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store 从目录加载的fixture，按Action索引
+type Store struct {
+	byAction map[string][]*Fixture
+}
+
+// LoadStore 加载目录下所有fixture（*.json，包含子目录），scenarios目录除外
+func LoadStore(dir string) (*Store, error) {
+	s := &Store{byAction: make(map[string][]*Fixture)}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && d.Name() == scenarioDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("parse fixture %s: %w", path, err)
+		}
+		if f.Action == "" {
+			return fmt.Errorf("fixture %s: missing Action", path)
+		}
+		s.Add(&f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fixtures := range s.byAction {
+		sort.SliceStable(fixtures, func(i, j int) bool {
+			return fixtures[i].RecordedAt.Before(fixtures[j].RecordedAt)
+		})
+	}
+	return s, nil
+}
+
+// Add 添加fixture
+func (s *Store) Add(f *Fixture) {
+	s.byAction[f.Action] = append(s.byAction[f.Action], f)
+}
+
+// Count fixture总数
+func (s *Store) Count() int {
+	n := 0
+	for _, fixtures := range s.byAction {
+		n += len(fixtures)
+	}
+	return n
+}
+
+// Find 查找与请求最匹配的fixture
+// 当前场景的fixture优先于默认场景，其次匹配参数越多越优先，最后取最新录制的
+func (s *Store) Find(action, scenario string, params map[string]interface{}) *Fixture {
+	var best *Fixture
+	bestScore := -1
+	for _, f := range s.byAction[action] {
+		if f.Scenario != "" && f.Scenario != scenario {
+			continue
+		}
+		if !f.matches(params) {
+			continue
+		}
+
+		score := len(f.Match)
+		if f.Scenario != "" {
+			score += 1000
+		}
+		if score >= bestScore {
+			best, bestScore = f, score
+		}
+	}
+	return best
+}
+
+// Recorder 把请求和响应保存为fixture文件：<dir>/<Action>/<时间戳>.json
+type Recorder struct {
+	dir      string
+	scenario string
+	actions  map[string]bool
+	match    map[string][]string
+	seq      uint64
+}
+
+// NewRecorder 创建录制器，actions为空时录制全部Action
+func NewRecorder(dir, scenario string, actions []string, match map[string][]string) *Recorder {
+	r := &Recorder{
+		dir:      dir,
+		scenario: scenario,
+		match:    match,
+	}
+	if len(actions) > 0 {
+		r.actions = make(map[string]bool, len(actions))
+		for _, action := range actions {
+			r.actions[action] = true
+		}
+	}
+	return r
+}
+
+// Enabled 是否需要录制该Action
+func (r *Recorder) Enabled(action string) bool {
+	return r.actions == nil || r.actions[action]
+}
+
+// Record 保存一次请求和响应，签名、token等敏感字段替换为[REDACTED]后再写入文件
+func (r *Recorder) Record(action string, request map[string]interface{}, status int, response map[string]interface{}) error {
+	f := &Fixture{
+		Action:     action,
+		Scenario:   r.scenario,
+		Match:      r.match[action],
+		Request:    logger.RedactMap(request),
+		Status:     status,
+		Response:   logger.RedactMap(response),
+		RecordedAt: time.Now(),
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(r.dir, action)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.json", f.RecordedAt.Format("20060102150405.000"), atomic.AddUint64(&r.seq, 1)%10000)
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
@@ -0,0 +1,122 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/api"
+)
+
+// scenarioDir fixture目录下存放自定义场景的子目录，每个场景一个JSON文件
+const scenarioDir = "scenarios"
+
+// 内置场景
+const (
+	SESSION_EXPIRED_SCENARIO   = "session-expired"   // 所有需要登录的Action返回401
+	USERNAME_COOLDOWN_SCENARIO = "username-cooldown" // 用户名处于24小时修改冷却期
+	RATE_LIMITED_SCENARIO      = "rate-limited"      // 所有请求被限流
+)
+
+// Scenario 脚本化的场景，在fixture的基础上覆盖部分Action的行为
+// 录制时Scenario字段相同的fixture也属于该场景，优先于默认场景的fixture
+type Scenario struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description,omitempty"`
+	// ExpireSession 为true时忽略mock session，需要登录的Action返回401
+	ExpireSession bool   `json:"ExpireSession,omitempty"`
+	Rules         []Rule `json:"Rules,omitempty"`
+}
+
+// Rule 覆盖某个Action的响应
+type Rule struct {
+	Action string                 `json:"Action"`          // *表示所有Action
+	Match  map[string]interface{} `json:"Match,omitempty"` // 请求参数需要等于这些值
+	Status int                    `json:"Status,omitempty"`
+	// Response 合并到fixture响应中的字段，没有fixture时作为完整响应
+	Response map[string]interface{} `json:"Response,omitempty"`
+}
+
+func (r *Rule) matches(action string, params map[string]interface{}) bool {
+	if r.Action != "*" && r.Action != action {
+		return false
+	}
+	for name, value := range r.Match {
+		if fmt.Sprint(value) != fmt.Sprint(params[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// BuiltinScenarios 内置场景，时间相关的字段按调用时的时间生成
+func BuiltinScenarios() map[string]*Scenario {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	return map[string]*Scenario{
+		SESSION_EXPIRED_SCENARIO: {
+			Name:          SESSION_EXPIRED_SCENARIO,
+			Description:   "session已过期，需要登录的Action返回401",
+			ExpireSession: true,
+		},
+		USERNAME_COOLDOWN_SCENARIO: {
+			Name:        USERNAME_COOLDOWN_SCENARIO,
+			Description: "用户名刚修改过，24小时内再次修改会被跳过",
+			Rules: []Rule{
+				{
+					Action:   api.GET_USER_PROFILE_LABEL,
+					Response: map[string]interface{}{"last_username_update": now},
+				},
+				{
+					Action: api.UPDATE_USER_PROFILE_LABEL,
+					Response: map[string]interface{}{
+						"last_username_update": now,
+						"username_updated":     false,
+					},
+				},
+			},
+		},
+		RATE_LIMITED_SCENARIO: {
+			Name:        RATE_LIMITED_SCENARIO,
+			Description: "所有请求被限流",
+			Rules: []Rule{
+				{
+					Action: "*",
+					Status: 429,
+					Response: map[string]interface{}{
+						"RetCode":    api.RATE_LIMITED_CODE,
+						"Message":    "Too many requests",
+						"RetryAfter": 30,
+					},
+				},
+			},
+		},
+	}
+}
+
+// LoadScenarios 加载内置场景和 <dir>/scenarios/*.json 中的自定义场景，同名时自定义场景优先
+func LoadScenarios(dir string) (map[string]*Scenario, error) {
+	scenarios := BuiltinScenarios()
+
+	paths, err := filepath.Glob(filepath.Join(dir, scenarioDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var s Scenario
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+		}
+		if s.Name == "" {
+			s.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		scenarios[s.Name] = &s
+	}
+	return scenarios, nil
+}
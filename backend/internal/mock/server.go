@@ -0,0 +1,323 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
+	"beast-royale-backend/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Options mock服务配置
+type Options struct {
+	CookieName string
+	Scenario   string // 启动时的场景
+	// Address 不为空时未登录的请求也视为该地址已登录，前端无需走钱包登录流程
+	Address string
+}
+
+// Server 用录制的fixture模拟 /api，供前端在没有MySQL、Redis和钱包的情况下开发
+type Server struct {
+	store     *Store
+	scenarios map[string]*Scenario
+	opts      Options
+
+	mu       sync.RWMutex
+	scenario string
+	sessions map[string]string // cookie -> 小写地址
+}
+
+// NewServer 创建mock服务
+func NewServer(store *Store, scenarios map[string]*Scenario, opts Options) *Server {
+	if opts.CookieName == "" {
+		opts.CookieName = "mock_session"
+	}
+	return &Server{
+		store:     store,
+		scenarios: scenarios,
+		opts:      opts,
+		scenario:  opts.Scenario,
+		sessions:  make(map[string]string),
+	}
+}
+
+// Register 注册 /api 和场景切换接口
+func (s *Server) Register(r gin.IRouter) {
+	r.POST("/api", s.handleAPI)
+	r.GET("/mock/scenarios", s.listScenarios)
+	r.PUT("/mock/scenario", s.setScenario)
+}
+
+// SetScenario 切换当前场景，空字符串表示默认场景
+func (s *Server) SetScenario(name string) bool {
+	if _, ok := s.scenarios[name]; name != "" && !ok {
+		return false
+	}
+	s.mu.Lock()
+	s.scenario = name
+	s.mu.Unlock()
+	logger.Info("mock场景切换为: %q", name)
+	return true
+}
+
+func (s *Server) currentScenario() (string, *Scenario) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.scenario, s.scenarios[s.scenario]
+}
+
+func (s *Server) handleAPI(c *gin.Context) {
+	requestCodec, ok := codec.ForContentType(c.ContentType())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"Success": false,
+			"Message": "Unsupported Content-Type",
+		})
+		return
+	}
+	c.Set("codec", codec.Negotiate(c.GetHeader("Accept"), requestCodec))
+
+	var params map[string]interface{}
+	body, err := io.ReadAll(c.Request.Body)
+	if err == nil {
+		err = requestCodec.Decode(body, &params)
+	}
+	if err != nil || params == nil {
+		codec.Render(c, http.StatusBadRequest, gin.H{
+			"Success": false,
+			"Message": "Invalid request body",
+		})
+		return
+	}
+
+	action, _ := params["Action"].(string)
+	if action == "" {
+		codec.Render(c, http.StatusBadRequest, gin.H{
+			"Success": false,
+			"Message": "Action field is required",
+			"Error":   "Missing Action field",
+		})
+		return
+	}
+	reqUUID, _ := params["RequestUUID"].(string)
+	if reqUUID == "" {
+		reqUUID = uuid.NewString()
+	}
+
+	status, response := s.respond(c, action, params)
+	if _, ok := response["Action"]; !ok && status < 400 {
+		response["Action"] = action + "Response"
+	}
+	response["RequestUUID"] = reqUUID
+
+	logger.Info("mock响应 - Action: %s, Status: %d, RetCode: %v", action, status, response["RetCode"])
+	codec.Render(c, status, response)
+}
+
+// respond 依次处理场景规则、鉴权、内置的登录流程和fixture
+func (s *Server) respond(c *gin.Context, action string, params map[string]interface{}) (int, map[string]interface{}) {
+	scenarioName, scenario := s.currentScenario()
+
+	// 返回错误状态的规则模拟中间件拒绝请求（例如限流），在鉴权之前生效
+	var rules []*Rule
+	if scenario != nil {
+		for i := range scenario.Rules {
+			rule := &scenario.Rules[i]
+			if !rule.matches(action, params) {
+				continue
+			}
+			if rule.Status >= 400 {
+				response := copyMap(rule.Response)
+				if _, ok := response["Action"]; !ok {
+					response["Action"] = action + "Response"
+				}
+				return rule.Status, response
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	if api.Exist(action) && api.GetActionAuthType(action) == api.COOKIEAUTH {
+		address := s.sessionAddress(c)
+		if address == "" || (scenario != nil && scenario.ExpireSession) {
+			return http.StatusUnauthorized, map[string]interface{}{
+				"RetCode": 401,
+				"Message": "Authentication required",
+				"Error":   "Session not found or expired",
+			}
+		}
+		params["Address"] = address
+	}
+
+	status := http.StatusOK
+	var response map[string]interface{}
+	if fixture := s.store.Find(action, scenarioName, params); fixture != nil {
+		if fixture.Status != 0 {
+			status = fixture.Status
+		}
+		response = copyMap(fixture.Response)
+	}
+
+	// 登录相关的Action即使有fixture也需要维护mock session
+	switch action {
+	case api.CONNECT_WALLET_LABEL:
+		if response == nil {
+			response = map[string]interface{}{
+				"RetCode": 0,
+				"Message": "Wallet connected successfully",
+				"nonce":   randomNonce(),
+			}
+		}
+	case api.VERIFY_SIGNATURE_LABEL:
+		address, _ := params[api.ADDRESS].(string)
+		if address == "" {
+			return http.StatusOK, map[string]interface{}{
+				"RetCode": 400,
+				"Message": "Address, Signature, and Nonce are required",
+			}
+		}
+		s.login(c, address)
+		if response == nil {
+			response = map[string]interface{}{
+				"RetCode": 0,
+				"Message": "Signature verified successfully",
+				"token":   "valid_token_" + strings.ToLower(address),
+			}
+		}
+	case "Logout":
+		s.logout(c)
+		if response == nil {
+			response = map[string]interface{}{
+				"RetCode": 0,
+				"Message": "Logout successful",
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		if response == nil {
+			response = make(map[string]interface{})
+		}
+		for k, v := range rule.Response {
+			response[k] = v
+		}
+		if rule.Status != 0 {
+			status = rule.Status
+		}
+	}
+
+	if response == nil {
+		return http.StatusOK, map[string]interface{}{
+			"RetCode": 404,
+			"Message": "No fixture for action: " + action,
+		}
+	}
+	if _, ok := response["RetCode"]; !ok {
+		response["RetCode"] = 0
+	}
+	return status, response
+}
+
+func (s *Server) sessionAddress(c *gin.Context) string {
+	if cookie, err := c.Cookie(s.opts.CookieName); err == nil {
+		s.mu.RLock()
+		address, ok := s.sessions[cookie]
+		s.mu.RUnlock()
+		if ok {
+			return address
+		}
+	}
+	return strings.ToLower(s.opts.Address)
+}
+
+func (s *Server) login(c *gin.Context, address string) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.sessions[token] = strings.ToLower(address)
+	s.mu.Unlock()
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (s *Server) logout(c *gin.Context) {
+	if cookie, err := c.Cookie(s.opts.CookieName); err == nil {
+		s.mu.Lock()
+		delete(s.sessions, cookie)
+		s.mu.Unlock()
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:   s.opts.CookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
+
+func (s *Server) listScenarios(c *gin.Context) {
+	active, _ := s.currentScenario()
+	list := make([]gin.H, 0, len(s.scenarios))
+	for _, scenario := range s.scenarios {
+		list = append(list, gin.H{
+			"Name":        scenario.Name,
+			"Description": scenario.Description,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i]["Name"].(string) < list[j]["Name"].(string)
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"Active":    active,
+		"Scenarios": list,
+		"Fixtures":  s.store.Count(),
+	})
+}
+
+func (s *Server) setScenario(c *gin.Context) {
+	var req struct {
+		Scenario string `json:"Scenario"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Success": false, "Message": "Invalid request"})
+		return
+	}
+	if !s.SetScenario(req.Scenario) {
+		c.JSON(http.StatusNotFound, gin.H{"Success": false, "Message": "Unknown scenario: " + req.Scenario})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Success": true, "Active": req.Scenario})
+}
+
+func randomNonce() int {
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return 100000
+	}
+	return int(n.Int64()) + 100000
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"beast-royale-backend/internal/logger"
+)
+
+// 服务端推送事件类型
+const (
+	USER_PROFILE_UPDATED_EVENT = "UserProfileUpdated"
+)
+
+// 客户端可以订阅的主题类型，主题名为 <类型>:<ID>，与eventbus.Room、eventbus.Guild对应
+const (
+	ROOM_TOPIC  = "room"
+	GUILD_TOPIC = "guild"
+)
+
+// MAX_TOPIC_LENGTH 主题名的最大长度
+const MAX_TOPIC_LENGTH = 128
+
+// 订阅主题失败的原因
+var (
+	ErrInvalidTopic         = errors.New("invalid topic")
+	ErrTopicForbidden       = errors.New("topic not allowed")
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+)
+
+// TopicAuthorizer 判断地址是否可以订阅某个房间或公会，id为主题中冒号后面的部分
+type TopicAuthorizer func(address, id string) bool
+
+// Event 服务端主动推送给客户端的事件
+type Event struct {
+	Event string      `json:"Event"`
+	Topic string      `json:"Topic,omitempty"`
+	Data  interface{} `json:"Data,omitempty"`
+	Time  int64       `json:"Time"`
+}
+
+// NewEvent 创建推送事件
+func NewEvent(eventType string, data interface{}) *Event {
+	return &Event{
+		Event: eventType,
+		Data:  data,
+		Time:  time.Now().UnixMilli(),
+	}
+}
+
+// Client 一个可接收推送的长连接（例如WebSocket）
+type Client interface {
+	// Address 连接所属的钱包地址（小写）
+	Address() string
+	// Deliver 非阻塞地投递消息，发送队列已满时返回false
+	Deliver(payload []byte) bool
+	// Close 关闭连接
+	Close()
+}
+
+// Hub 管理本实例上的所有长连接，按地址和主题投递事件
+type Hub struct {
+	mu        sync.RWMutex
+	byAddress map[string]map[Client]struct{}
+	byTopic   map[string]map[Client]struct{}
+	byClient  map[Client]map[string]struct{}
+	closed    bool
+
+	// authorizers 按主题类型判断是否允许订阅，没有设置的类型不允许订阅
+	authorizers map[string]TopicAuthorizer
+
+	// presenceListener 地址在本实例上线（第一个连接）或下线（最后一个连接断开）时回调
+	presenceListener func(address string, online bool)
+}
+
+// DefaultHub 进程内默认的推送中心
+var DefaultHub = NewHub()
+
+// NewHub 创建推送中心
+func NewHub() *Hub {
+	return &Hub{
+		byAddress:   make(map[string]map[Client]struct{}),
+		byTopic:     make(map[string]map[Client]struct{}),
+		byClient:    make(map[Client]map[string]struct{}),
+		authorizers: make(map[string]TopicAuthorizer),
+	}
+}
+
+// SetTopicAuthorizer 设置某类主题（ROOM_TOPIC、GUILD_TOPIC）的订阅权限检查，fn为nil时不再允许订阅该类主题
+func (h *Hub) SetTopicAuthorizer(kind string, fn TopicAuthorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if fn == nil {
+		delete(h.authorizers, kind)
+		return
+	}
+	h.authorizers[kind] = fn
+}
+
+// Authorize 检查地址是否可以订阅主题：主题必须是 room:<id> 或 guild:<id>，并且通过该类主题的权限检查
+func (h *Hub) Authorize(address, topic string) error {
+	kind, id, ok := strings.Cut(topic, ":")
+	if !ok || id == "" || len(topic) > MAX_TOPIC_LENGTH || (kind != ROOM_TOPIC && kind != GUILD_TOPIC) {
+		return ErrInvalidTopic
+	}
+
+	h.mu.RLock()
+	authorize := h.authorizers[kind]
+	h.mu.RUnlock()
+	if authorize == nil || !authorize(strings.ToLower(address), id) {
+		return ErrTopicForbidden
+	}
+	return nil
+}
+
+// SetPresenceListener 设置在线状态变化回调，用于多实例的在线状态跟踪
+func (h *Hub) SetPresenceListener(fn func(address string, online bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presenceListener = fn
+}
+
+// Register 注册连接，Hub已关闭时返回false
+func (h *Hub) Register(c Client) bool {
+	address := strings.ToLower(c.Address())
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return false
+	}
+	first := len(h.byAddress[address]) == 0
+	addClient(h.byAddress, address, c)
+	listener := h.presenceListener
+	h.mu.Unlock()
+
+	if first && listener != nil {
+		listener(address, true)
+	}
+	return true
+}
+
+// Unregister 注销连接并退出其订阅的所有主题
+func (h *Hub) Unregister(c Client) {
+	address := strings.ToLower(c.Address())
+
+	h.mu.Lock()
+	_, registered := h.byAddress[address][c]
+	removeClient(h.byAddress, address, c)
+	for topic := range h.byClient[c] {
+		removeClient(h.byTopic, topic, c)
+	}
+	delete(h.byClient, c)
+	last := registered && len(h.byAddress[address]) == 0
+	listener := h.presenceListener
+	h.mu.Unlock()
+
+	if last && listener != nil {
+		listener(address, false)
+	}
+}
+
+// Subscribe 订阅主题，连接已订阅limit个主题时返回ErrTooManySubscriptions，重复订阅同一主题不计数
+// 调用前先通过Authorize检查权限
+func (h *Hub) Subscribe(c Client, topic string, limit int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	topics := h.byClient[c]
+	if _, ok := topics[topic]; ok {
+		return nil
+	}
+	if len(topics) >= limit {
+		return ErrTooManySubscriptions
+	}
+	if topics == nil {
+		topics = make(map[string]struct{})
+		h.byClient[c] = topics
+	}
+	topics[topic] = struct{}{}
+	addClient(h.byTopic, topic, c)
+	return nil
+}
+
+// Unsubscribe 取消订阅主题
+func (h *Hub) Unsubscribe(c Client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	removeClient(h.byTopic, topic, c)
+	if topics, ok := h.byClient[c]; ok {
+		delete(topics, topic)
+		if len(topics) == 0 {
+			delete(h.byClient, c)
+		}
+	}
+}
+
+// PushToAddress 向某个地址的所有连接推送事件，返回成功投递的连接数
+func (h *Hub) PushToAddress(address string, event *Event) int {
+	h.mu.RLock()
+	clients := snapshot(h.byAddress[strings.ToLower(address)])
+	h.mu.RUnlock()
+	return h.deliver(clients, event)
+}
+
+// PushToTopic 向订阅了某个主题的所有连接推送事件，返回成功投递的连接数
+func (h *Hub) PushToTopic(topic string, event *Event) int {
+	h.mu.RLock()
+	clients := snapshot(h.byTopic[topic])
+	h.mu.RUnlock()
+
+	e := *event
+	e.Topic = topic
+	return h.deliver(clients, &e)
+}
+
+// Addresses 本实例上有连接的所有地址
+func (h *Hub) Addresses() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	addresses := make([]string, 0, len(h.byAddress))
+	for address := range h.byAddress {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// ConnectionCount 当前连接数
+func (h *Hub) ConnectionCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for _, clients := range h.byAddress {
+		n += len(clients)
+	}
+	return n
+}
+
+// CloseAll 关闭所有连接并拒绝新的连接，用于服务关闭
+func (h *Hub) CloseAll() {
+	h.mu.Lock()
+	h.closed = true
+	var clients []Client
+	for _, set := range h.byAddress {
+		clients = append(clients, snapshot(set)...)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		c.Close()
+	}
+}
+
+func (h *Hub) deliver(clients []Client, event *Event) int {
+	if len(clients) == 0 {
+		return 0
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("序列化推送事件失败 - Event: %s, Error: %v", event.Event, err)
+		return 0
+	}
+
+	delivered := 0
+	for _, c := range clients {
+		if c.Deliver(payload) {
+			delivered++
+			continue
+		}
+		// 发送队列已满说明客户端消费过慢，直接断开，避免拖垮服务端内存
+		logger.Error("推送队列已满，断开连接 - Address: %s, Event: %s", c.Address(), event.Event)
+		c.Close()
+	}
+	return delivered
+}
+
+func addClient(index map[string]map[Client]struct{}, key string, c Client) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[Client]struct{})
+		index[key] = set
+	}
+	set[c] = struct{}{}
+}
+
+func removeClient(index map[string]map[Client]struct{}, key string, c Client) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
+
+func snapshot(set map[Client]struct{}) []Client {
+	clients := make([]Client, 0, len(set))
+	for c := range set {
+		clients = append(clients, c)
+	}
+	return clients
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/redispool"
+)
+
+// Result 一次限流检查的结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // 窗口内最早的请求过期、额度恢复所需时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+
+	hit string // 允许时记录的这次请求，Refund时删除
+}
+
+// Limiter 滑动窗口限流器
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error)
+	// Refund 撤销Allow允许并记录的一次请求，用于同一请求的其他规则拒绝时归还额度
+	Refund(ctx context.Context, key string, result *Result) error
+}
+
+// New 根据配置创建限流器
+func New(cfg *config.RateLimitConfig) (Limiter, error) {
+	switch cfg.Backend {
+	case "memory":
+		return NewMemoryLimiter(), nil
+	case "redis":
+		pool := redispool.GetPool()
+		if pool == nil {
+			return nil, fmt.Errorf("redis pool is not initialized")
+		}
+		return NewRedisLimiter(pool, cfg.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SWEEP_INTERVAL 清理过期key的间隔，不再出现的key在窗口过期后的一个间隔内被删除
+const SWEEP_INTERVAL = time.Minute
+
+// memoryWindow 一个key在窗口内的请求
+type memoryWindow struct {
+	hits   []memoryHit
+	length time.Duration
+}
+
+// memoryHit 一次被允许的请求，id用于Refund
+type memoryHit struct {
+	at time.Time
+	id uint64
+}
+
+// MemoryLimiter 进程内滑动窗口限流器，仅适用于单实例部署和本地开发
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+	nextID    uint64
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 创建进程内限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		windows: make(map[string]*memoryWindow),
+		now:     time.Now,
+	}
+}
+
+// Allow 检查并记录一次请求
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var hits []memoryHit
+	if w, ok := l.windows[key]; ok {
+		hits = w.hits
+	}
+
+	// 丢弃窗口外的记录
+	i := 0
+	for i < len(hits) && !hits[i].at.After(now.Add(-window)) {
+		i++
+	}
+	hits = hits[i:]
+
+	var hit string
+	allowed := len(hits) < limit
+	if allowed {
+		l.nextID++
+		hits = append(hits, memoryHit{at: now, id: l.nextID})
+		hit = strconv.FormatUint(l.nextID, 10)
+	}
+
+	if len(hits) == 0 {
+		delete(l.windows, key)
+	} else {
+		l.windows[key] = &memoryWindow{hits: hits, length: window}
+	}
+
+	reset := window
+	if len(hits) > 0 {
+		reset = window - now.Sub(hits[0].at)
+	}
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  limit - len(hits),
+		ResetAfter: reset,
+		hit:        hit,
+	}
+	if !allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+// Refund 删除Allow记录的请求
+func (l *MemoryLimiter) Refund(ctx context.Context, key string, result *Result) error {
+	if result.hit == "" {
+		return nil
+	}
+	id, err := strconv.ParseUint(result.hit, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.windows[key]
+	if !ok {
+		return nil
+	}
+	for i, h := range w.hits {
+		if h.id == id {
+			w.hits = append(w.hits[:i:i], w.hits[i+1:]...)
+			break
+		}
+	}
+	if len(w.hits) == 0 {
+		delete(l.windows, key)
+	}
+	return nil
+}
+
+// Len 当前记录的key数量
+func (l *MemoryLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.windows)
+}
+
+// sweep 每隔SWEEP_INTERVAL删除所有记录都已过期的key，避免只出现一次的key（例如大量不同的IP）一直占用内存
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < SWEEP_INTERVAL {
+		return
+	}
+	l.lastSweep = now
+	for key, w := range l.windows {
+		if !w.hits[len(w.hits)-1].at.After(now.Add(-w.length)) {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterSweepsExpiredKeys(t *testing.T) {
+	now := time.Now()
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// 每个key只出现一次，不会再被检查
+	for i := 0; i < 100; i++ {
+		if _, err := l.Allow(ctx, fmt.Sprintf("ip:10.0.0.%d", i), 5, 10*time.Second); err != nil {
+			t.Fatalf("allow: %v", err)
+		}
+	}
+	if _, err := l.Allow(ctx, "long", 5, time.Hour); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if n := l.Len(); n != 101 {
+		t.Fatalf("got %d keys, want 101", n)
+	}
+
+	// 窗口过期后，下一次清理删除这些key，窗口未过期的key保留
+	now = now.Add(SWEEP_INTERVAL)
+	result, err := l.Allow(ctx, "other", 5, 10*time.Second)
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if !result.Allowed {
+		t.Fatal("request should be allowed")
+	}
+	if n := l.Len(); n != 2 {
+		t.Fatalf("got %d keys after sweep, want 2", n)
+	}
+}
+
+func TestMemoryLimiterLimit(t *testing.T) {
+	now := time.Now()
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if result, _ := l.Allow(ctx, "k", 3, time.Minute); !result.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	result, _ := l.Allow(ctx, "k", 3, time.Minute)
+	if result.Allowed || result.RetryAfter != time.Minute {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// 清理不影响窗口内的记录
+	now = now.Add(SWEEP_INTERVAL - time.Second)
+	if result, _ := l.Allow(ctx, "k", 3, time.Minute); result.Allowed {
+		t.Fatal("request should still be limited")
+	}
+}
+
+func TestMemoryLimiterRefund(t *testing.T) {
+	l := NewMemoryLimiter()
+	ctx := context.Background()
+
+	first, _ := l.Allow(ctx, "k", 2, time.Minute)
+	second, _ := l.Allow(ctx, "k", 2, time.Minute)
+	if !first.Allowed || !second.Allowed {
+		t.Fatal("requests should be allowed")
+	}
+	// 只归还指定的那一次请求
+	if err := l.Refund(ctx, "k", first); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if result, _ := l.Allow(ctx, "k", 2, time.Minute); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("unexpected result after refund: %+v", result)
+	}
+	rejected, _ := l.Allow(ctx, "k", 2, time.Minute)
+	if rejected.Allowed {
+		t.Fatal("request should be limited")
+	}
+	// 被拒绝的请求没有占用额度，归还时不删除其他记录
+	if err := l.Refund(ctx, "k", rejected); err != nil {
+		t.Fatalf("refund rejected: %v", err)
+	}
+	if result, _ := l.Allow(ctx, "k", 2, time.Minute); result.Allowed {
+		t.Fatal("request should still be limited")
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"beast-royale-backend/internal/tracing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+)
+
+// slidingWindowScript 基于有序集合的滑动窗口限流，时间取自Redis服务器，保证多实例时钟一致
+// 返回 {是否允许, 剩余次数, 额度恢复时间(ms)}
+var slidingWindowScript = redis.NewScript(1, `
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = window - (now - tonumber(oldest[2]))
+end
+
+return {allowed, limit - count, reset}
+`)
+
+// RedisLimiter 基于Redis的分布式限流器，多个后端实例共享计数
+type RedisLimiter struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// NewRedisLimiter 创建Redis限流器
+func NewRedisLimiter(pool *redis.Pool, prefix string) *RedisLimiter {
+	return &RedisLimiter{
+		pool:   pool,
+		prefix: prefix,
+	}
+}
+
+// Allow 检查并记录一次请求
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn = tracing.RedisConn(ctx, conn)
+
+	member := uuid.NewString()
+	values, err := redis.Int64s(slidingWindowScript.Do(conn,
+		l.prefix+":"+key, window.Milliseconds(), limit, member))
+	if err != nil {
+		return nil, err
+	}
+
+	reset := time.Duration(values[2]) * time.Millisecond
+	result := &Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: reset,
+	}
+	if result.Allowed {
+		result.hit = member
+	} else {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+// Refund 从有序集合中删除Allow记录的请求
+func (l *RedisLimiter) Refund(ctx context.Context, key string, result *Result) error {
+	if result.hit == "" {
+		return nil
+	}
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn = tracing.RedisConn(ctx, conn)
+
+	_, err = conn.Do("ZREM", l.prefix+":"+key, result.hit)
+	return err
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"sync/atomic"
+
+	"beast-royale-backend/internal/config"
+)
+
+// Rules 当前生效的限流规则，重新加载配置时整体替换，进行中的检查不受影响
+type Rules struct {
+	cfg atomic.Pointer[config.RateLimitConfig]
+}
+
+// NewRules 根据配置创建限流规则
+func NewRules(cfg *config.RateLimitConfig) *Rules {
+	r := &Rules{}
+	r.Update(cfg)
+	return r
+}
+
+// Update 替换限流规则，限流后端和开关修改后需要重启
+func (r *Rules) Update(cfg *config.RateLimitConfig) {
+	copied := *cfg
+	r.cfg.Store(&copied)
+}
+
+// Get 获取某个Action的限流规则
+func (r *Rules) Get(action string) config.RateLimitRule {
+	return r.cfg.Load().GetRule(action)
+}
@@ -0,0 +1,76 @@
+package redispool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"beast-royale-backend/internal/config"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+var Pool *redis.Pool
+
+// Init 初始化Redis连接池（session store之外的业务共用，例如限流）
+func Init(cfg *config.Config) error {
+	Pool = NewPool(cfg)
+
+	// 启动时检查一次连通性，避免运行时才发现Redis不可用
+	conn := Pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PING")
+	return err
+}
+
+// NewPool 根据配置创建Redis连接池
+func NewPool(cfg *config.Config) *redis.Pool {
+	addr := cfg.GetRedisAddr()
+	return &redis.Pool{
+		MaxIdle:     cfg.Redis.MinIdleConns,
+		MaxActive:   cfg.Redis.PoolSize,
+		IdleTimeout: 240 * time.Second,
+		Wait:        true,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr,
+				redis.DialPassword(cfg.Redis.Password),
+				redis.DialDatabase(cfg.Redis.DB),
+				redis.DialConnectTimeout(5*time.Second),
+			)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < time.Minute {
+				return nil
+			}
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+}
+
+// Ping 检查Redis是否可用，用于就绪检查
+func Ping(ctx context.Context) error {
+	if Pool == nil {
+		return errors.New("redis pool not initialized")
+	}
+	conn, err := Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = redis.DoContext(conn, ctx, "PING")
+	return err
+}
+
+// GetPool 获取Redis连接池
+func GetPool() *redis.Pool {
+	return Pool
+}
+
+// Close 关闭Redis连接池
+func Close() error {
+	if Pool == nil {
+		return nil
+	}
+	return Pool.Close()
+}
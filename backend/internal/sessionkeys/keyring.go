@@ -0,0 +1,263 @@
+package sessionkeys
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"beast-royale-backend/internal/config"
+
+	"github.com/gorilla/securecookie"
+	"gopkg.in/yaml.v3"
+)
+
+// 密钥长度：HMAC-SHA256签名密钥64字节，AES-256加密密钥32字节
+const (
+	AUTH_KEY_SIZE       = 64
+	ENCRYPTION_KEY_SIZE = 32
+)
+
+// LEGACY_KEY_ID 由security.session_secret生成的密钥ID，只用于验证旧的cookie
+const LEGACY_KEY_ID = "session_secret"
+
+// Key 一组session cookie密钥，使用base64保存
+type Key struct {
+	ID            string    `yaml:"id"`
+	CreatedAt     time.Time `yaml:"created_at"`
+	AuthKey       string    `yaml:"auth_key"`
+	EncryptionKey string    `yaml:"encryption_key,omitempty"` // 为空时cookie只签名不加密
+}
+
+// Keyring session cookie的密钥环
+// 第一个密钥用于签名和加密新的cookie，所有密钥都用于验证，
+// 轮换时把新密钥放在最前面，旧密钥保留到使用它的cookie都已被重新签发或过期
+type Keyring struct {
+	Keys []Key `yaml:"keys"`
+
+	pairs  [][]byte
+	codecs []securecookie.Codec
+}
+
+// NewKey 生成随机密钥
+func NewKey() (Key, error) {
+	auth := make([]byte, AUTH_KEY_SIZE)
+	encryption := make([]byte, ENCRYPTION_KEY_SIZE)
+	suffix := make([]byte, 4)
+	for _, b := range [][]byte{auth, encryption, suffix} {
+		if _, err := rand.Read(b); err != nil {
+			return Key{}, err
+		}
+	}
+	now := time.Now().UTC()
+	return Key{
+		ID:            now.Format("20060102") + "-" + hex.EncodeToString(suffix),
+		CreatedAt:     now,
+		AuthKey:       base64.StdEncoding.EncodeToString(auth),
+		EncryptionKey: base64.StdEncoding.EncodeToString(encryption),
+	}, nil
+}
+
+// Load 从文件读取密钥环
+func Load(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取session密钥文件失败: %w", err)
+	}
+	var k Keyring
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("解析session密钥文件失败: %w", err)
+	}
+	if err := k.init(); err != nil {
+		return nil, fmt.Errorf("session密钥文件 %s 不合法: %w", path, err)
+	}
+	return &k, nil
+}
+
+// FromConfig 根据安全配置创建密钥环
+// 配置了session_keyring时使用密钥文件；session_secret作为最后一个只用于验证的密钥，
+// 从单一密钥迁移到密钥环时已登录的用户不会被登出；只配置session_secret时它也用于签名
+func FromConfig(cfg *config.SecurityConfig) (*Keyring, error) {
+	k := &Keyring{}
+	if cfg.SessionKeyring != "" {
+		loaded, err := Load(cfg.SessionKeyring)
+		if err != nil {
+			return nil, err
+		}
+		k = loaded
+	}
+	if cfg.SessionSecret != "" {
+		k.Keys = append(k.Keys, Key{
+			ID:      LEGACY_KEY_ID,
+			AuthKey: base64.StdEncoding.EncodeToString([]byte(cfg.SessionSecret)),
+		})
+	}
+	if err := k.init(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Save 写入文件，先写临时文件再重命名，避免服务读到写了一半的文件
+func (k *Keyring) Save(path string) error {
+	if err := k.init(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+	header := "# session cookie密钥环，由 session-keys 命令生成，第一个密钥用于签名，所有密钥都用于验证\n"
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(header + string(data)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Rotate 生成新密钥
+// staged为false时新密钥立即用于签名；为true时先只用于验证，所有实例都更新后再用Promote切换，
+// 避免多实例滚动发布期间旧实例无法验证新密钥签发的cookie
+// keep为保留的旧密钥个数，超出的按创建时间从旧到新删除，<0时不删除
+func (k *Keyring) Rotate(staged bool, keep int) (Key, error) {
+	key, err := NewKey()
+	if err != nil {
+		return Key{}, err
+	}
+	if staged {
+		k.Keys = append(k.Keys, key)
+	} else {
+		k.Keys = append([]Key{key}, k.Keys...)
+	}
+	k.prune(key.ID, keep)
+	return key, k.init()
+}
+
+// Promote 把指定的密钥切换为签名密钥
+func (k *Keyring) Promote(id string) error {
+	for i, key := range k.Keys {
+		if key.ID != id {
+			continue
+		}
+		keys := append([]Key{key}, k.Keys[:i]...)
+		k.Keys = append(keys, k.Keys[i+1:]...)
+		return k.init()
+	}
+	return fmt.Errorf("key %s not found", id)
+}
+
+// prune 只保留签名密钥、新密钥和最新的keep个旧密钥
+func (k *Keyring) prune(newID string, keep int) {
+	if keep < 0 || len(k.Keys) == 0 {
+		return
+	}
+	primary := k.Keys[0].ID
+	var old []Key
+	for _, key := range k.Keys {
+		if key.ID != primary && key.ID != newID {
+			old = append(old, key)
+		}
+	}
+	if len(old) <= keep {
+		return
+	}
+	sort.SliceStable(old, func(i, j int) bool { return old[i].CreatedAt.After(old[j].CreatedAt) })
+	removed := make(map[string]bool)
+	for _, key := range old[keep:] {
+		removed[key.ID] = true
+	}
+	kept := k.Keys[:0]
+	for _, key := range k.Keys {
+		if !removed[key.ID] {
+			kept = append(kept, key)
+		}
+	}
+	k.Keys = kept
+}
+
+// init 校验密钥并生成securecookie使用的密钥对
+func (k *Keyring) init() error {
+	if len(k.Keys) == 0 {
+		return errors.New("no session key configured")
+	}
+	seen := make(map[string]bool, len(k.Keys))
+	pairs := make([][]byte, 0, len(k.Keys)*2)
+	for _, key := range k.Keys {
+		if key.ID == "" {
+			return errors.New("session key id is required")
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate session key id %s", key.ID)
+		}
+		seen[key.ID] = true
+
+		auth, err := base64.StdEncoding.DecodeString(key.AuthKey)
+		if err != nil || len(auth) == 0 {
+			return fmt.Errorf("session key %s: invalid auth_key", key.ID)
+		}
+		var encryption []byte
+		if key.EncryptionKey != "" {
+			encryption, err = base64.StdEncoding.DecodeString(key.EncryptionKey)
+			if err != nil {
+				return fmt.Errorf("session key %s: invalid encryption_key", key.ID)
+			}
+			// AES密钥只能是16、24或32字节
+			if n := len(encryption); n != 16 && n != 24 && n != 32 {
+				return fmt.Errorf("session key %s: encryption_key must be 16, 24 or 32 bytes, got %d", key.ID, n)
+			}
+		}
+		pairs = append(pairs, auth, encryption)
+	}
+	k.pairs = pairs
+	k.codecs = securecookie.CodecsFromPairs(pairs...)
+	return nil
+}
+
+// KeyPairs 按顺序返回签名和加密密钥对，用于创建session存储
+func (k *Keyring) KeyPairs() [][]byte {
+	return k.pairs
+}
+
+// Primary 当前用于签名的密钥ID
+func (k *Keyring) Primary() string {
+	return k.Keys[0].ID
+}
+
+// Encrypted 新签发的cookie是否加密，即签名密钥是否配置了encryption_key
+func (k *Keyring) Encrypted() bool {
+	return k.Keys[0].EncryptionKey != ""
+}
+
+// NeedsReissue 请求中的session cookie是否由旧密钥签发，需要用当前签名密钥重新签发
+func (k *Keyring) NeedsReissue(r *http.Request, name string) bool {
+	if len(k.codecs) < 2 {
+		return false
+	}
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return false
+	}
+	var value string
+	if k.codecs[0].Decode(name, cookie.Value, &value) == nil {
+		return false
+	}
+	return securecookie.DecodeMulti(name, cookie.Value, &value, k.codecs[1:]...) == nil
+}
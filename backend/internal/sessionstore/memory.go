@@ -0,0 +1,174 @@
+package sessionstore
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// sweepInterval 清理过期session的最小间隔，清理在Save时顺带进行
+const sweepInterval = time.Minute
+
+// memorySession 内存中保存的一个session
+type memorySession struct {
+	values  map[interface{}]interface{}
+	expires time.Time
+}
+
+// MemoryStore 进程内的session存储，cookie中只保存签名后的session ID
+// session在MaxAge秒后过期，与Redis存储的TTL行为一致；数据不在实例间共享，重启后丢失
+type MemoryStore struct {
+	codecs  []securecookie.Codec
+	options *gsessions.Options
+
+	mu        sync.Mutex
+	sessions  map[string]*memorySession
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建内存session存储，keyPairs与securecookie相同，按签名密钥、加密密钥成对传入
+func NewMemoryStore(keyPairs ...[]byte) *MemoryStore {
+	return &MemoryStore{
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		options: &gsessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		sessions: make(map[string]*memorySession),
+		now:      time.Now,
+	}
+}
+
+// WithClock 替换获取当前时间的函数，测试中用于模拟session过期
+func (s *MemoryStore) WithClock(now func() time.Time) *MemoryStore {
+	s.mu.Lock()
+	s.now = now
+	s.mu.Unlock()
+	return s
+}
+
+// Options 设置新session的默认属性
+func (s *MemoryStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+// Get 获取session，同一个请求内多次获取返回同一个session
+func (s *MemoryStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New 根据请求中的cookie加载session，cookie无效或session已过期时返回新的session
+func (s *MemoryStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.codecs...); err != nil {
+		return session, err
+	}
+	if s.load(session) {
+		session.IsNew = false
+	}
+	return session, nil
+}
+
+// Save 保存session并写出cookie，MaxAge<=0时删除session
+func (s *MemoryStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		s.mu.Lock()
+		delete(s.sessions, session.ID)
+		s.mu.Unlock()
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	s.sessions[session.ID] = &memorySession{
+		values:  copyValues(session.Values),
+		expires: now.Add(time.Duration(session.Options.MaxAge) * time.Second),
+	}
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+	s.mu.Unlock()
+
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Len 未过期的session数量
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(s.now())
+	return len(s.sessions)
+}
+
+// load 读取session数据，已过期时删除并返回false
+func (s *MemoryStore) load(session *gsessions.Session) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.sessions[session.ID]
+	if !ok {
+		return false
+	}
+	if !s.now().Before(stored.expires) {
+		delete(s.sessions, session.ID)
+		return false
+	}
+	session.Values = copyValues(stored.values)
+	return true
+}
+
+// sweep 删除所有过期的session，调用方需持有锁
+func (s *MemoryStore) sweep(now time.Time) {
+	for id, stored := range s.sessions {
+		if !now.Before(stored.expires) {
+			delete(s.sessions, id)
+		}
+	}
+	s.lastSweep = now
+}
+
+// copyValues 复制session数据，避免请求间共享同一个map
+func copyValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	copied := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(b), "="), nil
+}
@@ -0,0 +1,92 @@
+package sessionstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+)
+
+const testSessionName = "sessionid"
+
+// save 保存session，返回浏览器之后会带上的cookie
+func save(t *testing.T, s *MemoryStore, cookie *http.Cookie, values map[interface{}]interface{}) *http.Cookie {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	session, err := s.New(r, testSessionName)
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	for k, v := range values {
+		session.Values[k] = v
+	}
+	w := httptest.NewRecorder()
+	if err := s.Save(r, w, session); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	return cookies[0]
+}
+
+// load 用cookie读取session，返回session是否存在
+func load(t *testing.T, s *MemoryStore, cookie *http.Cookie) bool {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	session, err := s.New(r, testSessionName)
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	return !session.IsNew && session.Values["address"] == "0xabc"
+}
+
+func TestMemoryStoreExpiresAfterTTL(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore([]byte("0123456789abcdef0123456789abcdef")).WithClock(func() time.Time { return now })
+	s.Options(sessions.Options{Path: "/", MaxAge: 60})
+
+	cookie := save(t, s, nil, map[interface{}]interface{}{"address": "0xabc"})
+	now = now.Add(59 * time.Second)
+	if !load(t, s, cookie) {
+		t.Fatal("session should be valid before the TTL")
+	}
+
+	// 没有再保存，TTL过后即使cookie还在也失效
+	now = now.Add(time.Second)
+	if load(t, s, cookie) {
+		t.Fatal("session should expire after the TTL")
+	}
+	if n := s.Len(); n != 0 {
+		t.Fatalf("got %d sessions, want 0", n)
+	}
+}
+
+func TestMemoryStoreRefreshesOnSave(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore([]byte("0123456789abcdef0123456789abcdef")).WithClock(func() time.Time { return now })
+	s.Options(sessions.Options{Path: "/", MaxAge: 60})
+
+	cookie := save(t, s, nil, map[interface{}]interface{}{"address": "0xabc"})
+
+	// 客户端持续使用时每次保存都重新计算过期时间，总时长超过TTL仍然有效
+	for i := 0; i < 5; i++ {
+		now = now.Add(45 * time.Second)
+		if !load(t, s, cookie) {
+			t.Fatalf("session expired after %d requests", i)
+		}
+		cookie = save(t, s, cookie, nil)
+	}
+
+	now = now.Add(time.Minute)
+	if load(t, s, cookie) {
+		t.Fatal("session should expire after the client stops using it")
+	}
+}
@@ -0,0 +1,63 @@
+package sessionstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/sessionkeys"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/redis"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// ErrCookieNotEncrypted cookie存储的签名密钥没有加密密钥，session数据会以明文保存在cookie中
+var ErrCookieNotEncrypted = errors.New("cookie session store requires an encryption key, configure security.session_keyring (session_secret only signs cookies)")
+
+// New 根据security.session_store创建session存储，cookie由keyring签名和加密
+//   - redis：session数据保存在Redis，多实例共享，生产环境使用
+//   - memory：保存在进程内存，按session_timeout过期，只用于测试和本地开发
+//   - cookie：session数据保存在加密的cookie中，不依赖服务端存储，cookie大小限制为4KB；
+//     服务端不保存session，登出只能让浏览器删除cookie，复制出去的cookie在session_timeout内仍然有效
+func New(cfg *config.Config, keyring *sessionkeys.Keyring) (sessions.Store, error) {
+	switch cfg.Security.SessionStore {
+	case "redis":
+		store, err := redis.NewStoreWithDB(
+			cfg.Redis.PoolSize,
+			"tcp",
+			cfg.GetRedisAddr(),
+			"",
+			cfg.Redis.Password,
+			strconv.Itoa(cfg.Redis.DB),
+			keyring.KeyPairs()...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("init redis session store: %w", err)
+		}
+		return store, nil
+	case "memory":
+		return NewMemoryStore(keyring.KeyPairs()...), nil
+	case "cookie":
+		if !keyring.Encrypted() {
+			return nil, ErrCookieNotEncrypted
+		}
+		store := gsessions.NewCookieStore(keyring.KeyPairs()...)
+		// securecookie默认接受30天内签发的cookie，改为与session_timeout一致
+		store.MaxAge(cfg.Security.SessionTimeout)
+		return &cookieStore{store}, nil
+	default:
+		return nil, fmt.Errorf("unknown session store: %s", cfg.Security.SessionStore)
+	}
+}
+
+// cookieStore 与gin-contrib的cookie存储相同，但保留创建时设置的cookie有效期
+type cookieStore struct {
+	*gsessions.CookieStore
+}
+
+// Options 设置新session的默认属性，只修改cookie属性，不影响securecookie校验的有效期
+func (s *cookieStore) Options(options sessions.Options) {
+	s.CookieStore.Options = options.ToGorillaOptions()
+}
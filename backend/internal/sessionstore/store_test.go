@@ -0,0 +1,32 @@
+package sessionstore
+
+import (
+	"errors"
+	"testing"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/sessionkeys"
+)
+
+func TestCookieStoreRequiresEncryption(t *testing.T) {
+	cfg := &config.Config{Security: config.SecurityConfig{
+		SessionStore:   "cookie",
+		SessionSecret:  "0123456789abcdef0123456789abcdef",
+		SessionTimeout: 3600,
+	}}
+	keyring, err := sessionkeys.FromConfig(&cfg.Security)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(cfg, keyring); !errors.Is(err, ErrCookieNotEncrypted) {
+		t.Fatalf("got %v, want ErrCookieNotEncrypted", err)
+	}
+
+	// 新生成的密钥带有加密密钥，用于签发新的cookie
+	if _, err := keyring.Rotate(false, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(cfg, keyring); err != nil {
+		t.Fatalf("encrypted keyring: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package testharness
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client 带cookie jar的HTTP客户端，模拟一个浏览器会话
+type Client struct {
+	t    testing.TB
+	h    *Harness
+	HTTP *http.Client
+	// Header 每个请求都会带上的请求头，例如 X-Admin-Key
+	Header http.Header
+}
+
+// Response 一次请求的结果，Body只在响应为JSON时解析
+type Response struct {
+	Status int
+	Body   map[string]interface{}
+	Raw    []byte
+}
+
+// NewClient 创建一个新的会话，不同的Client之间不共享cookie
+func (h *Harness) NewClient() *Client {
+	h.t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		h.t.Fatalf("create cookie jar: %v", err)
+	}
+	return &Client{t: h.t, h: h, HTTP: &http.Client{Jar: jar}, Header: http.Header{}}
+}
+
+// Call 通过统一端点 POST /api 调用Action
+func (c *Client) Call(action string, params map[string]interface{}) *Response {
+	c.t.Helper()
+	body := map[string]interface{}{"Action": action}
+	for k, v := range params {
+		body[k] = v
+	}
+	return c.Do(http.MethodPost, "/api", body)
+}
+
+// Do 发送JSON请求，body为nil时不带请求体
+func (c *Client) Do(method, path string, body interface{}) *Response {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("marshal request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.h.URL()+path, reader)
+	if err != nil {
+		c.t.Fatalf("create request: %v", err)
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("read response: %v", err)
+	}
+	result := &Response{Status: resp.StatusCode, Raw: raw}
+	// /metrics等非JSON响应只保留Raw
+	if len(raw) > 0 && strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		if err := json.Unmarshal(raw, &result.Body); err != nil {
+			c.t.Fatalf("%s %s: invalid JSON response %q: %v", method, path, raw, err)
+		}
+	}
+	return result
+}
+
+// Login 完成 ConnectWallet → VerifySignature 登录流程，任一步失败时测试失败
+func (c *Client) Login(w *Wallet) *Response {
+	c.t.Helper()
+	connected := c.Call("ConnectWallet", map[string]interface{}{"Address": w.Address})
+	connected.ExpectRetCode(c.t, 0)
+	nonce := connected.Int("nonce")
+	verified := c.Call("VerifySignature", map[string]interface{}{
+		"Address":   w.Address,
+		"Signature": w.SignLogin(nonce),
+		"Nonce":     nonce,
+	})
+	verified.ExpectRetCode(c.t, 0)
+	return verified
+}
+
+// RetCode 响应中的业务返回码
+func (r *Response) RetCode() int {
+	return r.Int("RetCode")
+}
+
+// String 响应中的字符串字段
+func (r *Response) String(key string) string {
+	s, _ := r.Body[key].(string)
+	return s
+}
+
+// Int 响应中的数字字段
+func (r *Response) Int(key string) int {
+	n, _ := r.Body[key].(float64)
+	return int(n)
+}
+
+// Bool 响应中的布尔字段
+func (r *Response) Bool(key string) bool {
+	b, _ := r.Body[key].(bool)
+	return b
+}
+
+// ExpectRetCode 检查业务返回码，不一致时输出完整的响应
+func (r *Response) ExpectRetCode(t testing.TB, retCode int) *Response {
+	t.Helper()
+	if got := r.RetCode(); got != retCode {
+		t.Fatalf("RetCode = %d, want %d, response: %s", got, retCode, r.Raw)
+	}
+	return r
+}
+
+// DialWebSocket 使用当前会话的cookie和Header连接 /ws，测试结束时自动关闭（在Harness等待处理函数退出之前）
+func (c *Client) DialWebSocket() (*websocket.Conn, *http.Response, error) {
+	c.t.Helper()
+	dialer := websocket.Dialer{Jar: c.HTTP.Jar}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(c.h.URL(), "http")+"/ws", c.Header.Clone())
+	if err != nil {
+		return nil, resp, err
+	}
+	c.t.Cleanup(func() { conn.Close() })
+	return conn, resp, nil
+}
+
+// SendFrame 通过WebSocket发送一帧并读取下一条消息
+func SendFrame(t testing.TB, conn *websocket.Conn, frame map[string]interface{}) *Response {
+	t.Helper()
+	if err := conn.WriteJSON(frame); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+	return ReadFrame(t, conn)
+}
+
+// ReadFrame 读取WebSocket的下一条消息，例如服务端推送的事件
+func ReadFrame(t testing.TB, conn *websocket.Conn) *Response {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	result := &Response{Status: http.StatusOK, Raw: raw}
+	if err := json.Unmarshal(raw, &result.Body); err != nil {
+		t.Fatalf("invalid JSON frame %q: %v", raw, err)
+	}
+	return result
+}
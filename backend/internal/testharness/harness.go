@@ -0,0 +1,205 @@
+// Package testharness 在进程内启动完整的服务，用于端到端测试
+//
+// Harness 使用 server.NewRouter 创建路由，Redis由miniredis代替，数据库使用执行过迁移的SQLite内存数据库，
+// 配置经过与 run 命令相同的加载和校验。任务的依赖通过 server.NewRouter 传入，
+// 同一时间只能有一个Harness（日志、指标等仍是进程级的），使用Harness的测试不要调用 t.Parallel。
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/db"
+	"beast-royale-backend/internal/lifecycle"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/sessionkeys"
+	"beast-royale-backend/server"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+)
+
+// Harness 一个在进程内运行的服务
+type Harness struct {
+	t testing.TB
+
+	// Config 服务使用的配置
+	Config *config.Config
+	// Server 服务的HTTP地址为 Server.URL
+	Server *httptest.Server
+	// Redis 代替Redis的miniredis，可以用FastForward模拟过期
+	Redis *miniredis.Miniredis
+	// DB 服务使用的数据库，测试中可以直接准备或检查数据
+	DB *gorm.DB
+}
+
+// Option 修改Harness的配置
+type Option func(o *options)
+
+type options struct {
+	overrides []string
+	configure []func(cfg *config.Config)
+	logOutput io.Writer
+}
+
+// WithSessionStore 选择session存储：redis（默认，使用miniredis）、memory或cookie
+func WithSessionStore(store string) Option {
+	return WithOverrides("security.session_store=" + store)
+}
+
+// WithOverrides 与命令行的 --set 相同，按 key=value 覆盖配置，经过配置校验
+func WithOverrides(overrides ...string) Option {
+	return func(o *options) {
+		o.overrides = append(o.overrides, overrides...)
+	}
+}
+
+// WithConfig 在配置加载和校验之后修改配置，用于map等不能通过key=value设置的配置项
+func WithConfig(fn func(cfg *config.Config)) Option {
+	return func(o *options) {
+		o.configure = append(o.configure, fn)
+	}
+}
+
+// WithLogOutput 把服务的日志写入w，用于检查日志内容；w需要支持并发写入
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.logOutput = w
+	}
+}
+
+// New 启动服务，测试结束时自动关闭
+func New(t testing.TB, opts ...Option) *Harness {
+	t.Helper()
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t.Setenv("GIN_MODE", "test")
+
+	mr := miniredis.RunT(t)
+	cfg, err := loadConfig(t, mr, o.overrides)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	for _, fn := range o.configure {
+		fn(cfg)
+	}
+	if o.logOutput != nil {
+		err = logger.InitWriter(cfg.Logging, o.logOutput)
+	} else {
+		err = logger.Init(cfg.Logging)
+	}
+	if err != nil {
+		t.Fatalf("init logger: %v", err)
+	}
+
+	gdb, err := openDB(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	inflight := &lifecycle.Tracker{}
+	r, err := server.NewRouter(inflight, cfg, &api.Deps{Store: db.NewStore(gdb)})
+	if err != nil {
+		t.Fatalf("create router: %v", err)
+	}
+	srv := httptest.NewServer(r)
+	// 测试中建立的WebSocket连接在此之前关闭；httptest不等待已接管的连接，
+	// 需要等所有处理函数退出，避免它们在下一个测试中继续使用数据库和日志
+	t.Cleanup(func() {
+		srv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := inflight.Wait(ctx); err != nil {
+			t.Errorf("wait for %d in-flight requests: %v", inflight.Count(), err)
+		}
+	})
+
+	return &Harness{
+		t:      t,
+		Config: cfg,
+		Server: srv,
+		Redis:  mr,
+		DB:     gdb,
+	}
+}
+
+// URL 服务地址
+func (h *Harness) URL() string {
+	return h.Server.URL
+}
+
+// loadConfig 生成最小的配置文件，经过与run命令相同的加载、默认值和校验
+func loadConfig(t testing.TB, mr *miniredis.Miniredis, overrides []string) (*config.Config, error) {
+	dir := t.TempDir()
+	// 使用带加密密钥的密钥环，三种session存储都可以使用
+	key, err := sessionkeys.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	keyring := &sessionkeys.Keyring{Keys: []sessionkeys.Key{key}}
+	keyringPath := filepath.Join(dir, "session_keys.yaml")
+	if err := keyring.Save(keyringPath); err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(mr.Port())
+	if err != nil {
+		return nil, err
+	}
+	content := fmt.Sprintf(`server:
+  host: "127.0.0.1"
+redis:
+  host: %q
+  port: %d
+database:
+  driver: "sqlite"
+  path: ":memory:"
+security:
+  session_keyring: %q
+  session_store: "redis"
+  session_name: "sessionid"
+  cookie_name: "sessionid"
+logging:
+  level: "warn"
+  output: "stdout"
+rate_limit:
+  enabled: false
+  backend: "memory"
+`, mr.Host(), port, keyringPath)
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return nil, err
+	}
+	return config.LoadConfig(path, overrides...)
+}
+
+// openDB 打开数据库并执行所有迁移，与生产环境使用同一套迁移文件
+func openDB(cfg *config.Config) (*gorm.DB, error) {
+	gdb, err := db.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m, err := db.NewMigrator(gdb, cfg.Database.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		return nil, err
+	}
+	return gdb, nil
+}
@@ -0,0 +1,44 @@
+package testharness
+
+import (
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+
+	"beast-royale-backend/internal/wallet"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Wallet 测试用的钱包，每次创建时随机生成secp256k1私钥
+type Wallet struct {
+	t   testing.TB
+	Key *ecdsa.PrivateKey
+	// Address EIP-55格式的地址，服务端统一按小写保存
+	Address string
+}
+
+// NewWallet 生成一个新钱包
+func NewWallet(t testing.TB) *Wallet {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return &Wallet{t: t, Key: key, Address: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+}
+
+// LowerAddress 服务端保存和返回的小写地址
+func (w *Wallet) LowerAddress() string {
+	return strings.ToLower(w.Address)
+}
+
+// SignLogin 按前端的方式签名登录消息
+func (w *Wallet) SignLogin(nonce int) string {
+	w.t.Helper()
+	signature, err := wallet.SignMessage(w.Key, wallet.LoginMessage(nonce))
+	if err != nil {
+		w.t.Fatalf("sign login message: %v", err)
+	}
+	return signature
+}
@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisConn 为每个命令创建span的Redis连接
+type redisConn struct {
+	redis.Conn
+	ctx context.Context
+}
+
+// RedisConn 包装连接池取出的连接，连接上的命令挂到ctx的trace下
+func RedisConn(ctx context.Context, conn redis.Conn) redis.Conn {
+	return &redisConn{Conn: conn, ctx: ctx}
+}
+
+func (c *redisConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	// redigo用空命令刷新pipeline，没有实际意义
+	if commandName == "" {
+		return c.Conn.Do(commandName, args...)
+	}
+	_, span := Start(c.ctx, "redis."+strings.ToUpper(commandName),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", strings.ToUpper(commandName)),
+		),
+	)
+	reply, err := c.Conn.Do(commandName, args...)
+	// 空结果和脚本未缓存（redis.Script随后会改用EVAL）不视为错误
+	if err == redis.ErrNil || (err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")) {
+		span.SetAttributes(attribute.String("db.redis.reply", err.Error()))
+		End(span, nil)
+	} else {
+		End(span, err)
+	}
+	return reply, err
+}
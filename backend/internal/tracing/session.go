@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	gsessions "github.com/gorilla/sessions"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sessionStore 为session的读写创建span，Redis session存储的I/O都在这些span内
+type sessionStore struct {
+	sessions.Store
+}
+
+// InstrumentSessionStore 包装session存储，Get/New/Save各创建一个span
+func InstrumentSessionStore(store sessions.Store) sessions.Store {
+	return &sessionStore{Store: store}
+}
+
+// Get 通过请求上的registry获取session，同一个请求内多次获取（sessions中间件、重新签发cookie等）返回同一个session，
+// gin-contrib保存的也是这个session；registry中没有时调用New
+func (s *sessionStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	_, span := Start(r.Context(), "session.get", trace.WithSpanKind(trace.SpanKindClient))
+	session, err := gsessions.GetRegistry(r).Get(s, name)
+	End(span, err)
+	return session, err
+}
+
+func (s *sessionStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	_, span := Start(r.Context(), "session.new", trace.WithSpanKind(trace.SpanKindClient))
+	session, err := s.Store.New(r, name)
+	End(span, err)
+	return s.bind(session), err
+}
+
+func (s *sessionStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	_, span := Start(r.Context(), "session.save", trace.WithSpanKind(trace.SpanKindClient))
+	err := s.Store.Save(r, w, session)
+	End(span, err)
+	return err
+}
+
+// bind 把底层存储新建的session转交给包装，使 session.Save() 也经过包装
+// 底层存储的New不会把session注册到registry，转交后只有绑定到包装的session被注册和使用，不会出现两份
+func (s *sessionStore) bind(session *gsessions.Session) *gsessions.Session {
+	if session == nil {
+		return nil
+	}
+	bound := gsessions.NewSession(s, session.Name())
+	bound.ID = session.ID
+	bound.Values = session.Values
+	bound.Options = session.Options
+	bound.IsNew = session.IsNew
+	return bound
+}
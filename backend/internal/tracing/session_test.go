@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	gsessions "github.com/gorilla/sessions"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentedSessionPersistsHandlerValues(t *testing.T) {
+	// 开启追踪，span导出到内存
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	gin.SetMode(gin.TestMode)
+	store := InstrumentSessionStore(cookie.NewStore([]byte("0123456789abcdef0123456789abcdef")))
+	r := gin.New()
+	r.Use(sessions.Sessions("sid", store))
+	r.POST("/login", func(c *gin.Context) {
+		// 中间件等其他代码在同一个请求中直接从存储读取session，拿到的必须是同一个session
+		direct, err := store.Get(c.Request, "sid")
+		if err != nil {
+			t.Errorf("get session: %v", err)
+		}
+		session := sessions.Default(c)
+		session.Set("address", "0xabc")
+		if handler, ok := session.(interface{ Session() *gsessions.Session }); !ok || handler.Session() != direct {
+			t.Errorf("session read from the store is a copy of the handler's session")
+		}
+		if err := session.Save(); err != nil {
+			t.Errorf("save session: %v", err)
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/me", func(c *gin.Context) {
+		address, _ := sessions.Default(c).Get("address").(string)
+		c.String(http.StatusOK, address)
+	})
+
+	login := httptest.NewRecorder()
+	r.ServeHTTP(login, httptest.NewRequest(http.MethodPost, "/login", nil))
+	cookies := login.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want the session cookie", len(cookies))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookies[0])
+	me := httptest.NewRecorder()
+	r.ServeHTTP(me, req)
+	if me.Body.String() != "0xabc" {
+		t.Fatalf("session value not persisted, got %q", me.Body.String())
+	}
+
+	// 保存经过包装，记录了span
+	saved := false
+	for _, span := range exporter.GetSpans() {
+		saved = saved || span.Name == "session.save"
+	}
+	if !saved {
+		t.Fatalf("session.save span not recorded: %v", exporter.GetSpans())
+	}
+}
@@ -0,0 +1,12 @@
+package version
+
+// 构建信息，编译时通过ldflags注入：
+//
+//	go build -ldflags "-X beast-royale-backend/internal/version.Version=v1.2.0 -X beast-royale-backend/internal/version.Commit=abc1234"
+//
+// Makefile的build目标会自动从git读取
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = ""
+)
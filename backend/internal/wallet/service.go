@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"beast-royale-backend/internal/logger"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -10,6 +11,27 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// loginMessageTemplate 登录签名消息模板，与前端保持一致
+const loginMessageTemplate = "连接Beast Royale游戏\n\n点击签名以验证您的身份。\n\nNonce: %d"
+
+// LoginMessage 构造登录时需要签名的消息
+func LoginMessage(nonce int) string {
+	return fmt.Sprintf(loginMessageTemplate, nonce)
+}
+
+// SignMessage 按personal_sign（ethers.js signMessage）格式签名，返回0x开头的十六进制签名，恢复ID为27/28
+func SignMessage(key *ecdsa.PrivateKey, message string) (string, error) {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	messageHash := crypto.Keccak256Hash([]byte(prefix + message))
+
+	signature, err := crypto.Sign(messageHash.Bytes(), key)
+	if err != nil {
+		return "", err
+	}
+	signature[64] += 27
+	return "0x" + hex.EncodeToString(signature), nil
+}
+
 // WalletService 钱包服务
 type WalletService struct{}
 
@@ -29,19 +51,19 @@ func (ws *WalletService) GenerateNonce() (string, error) {
 
 // VerifySignature 验证以太坊签名
 func (ws *WalletService) VerifySignature(address, signature, message string) (bool, error) {
-	// 添加调试日志
-	logger.Info("=== 签名验证调试信息 ===")
-	logger.Info("地址: %s", address)
-	logger.Info("签名: %s", signature)
-	logger.Info("消息: %q", message)
-	logger.Info("消息长度: %d", len(message))
+	// 签名验证的完整过程只在开启signature跟踪日志时输出
+	logger.Trace(logger.SIGNATURE_TRACE, "=== 签名验证调试信息 ===")
+	logger.Trace(logger.SIGNATURE_TRACE, "地址: %s", address)
+	logger.Trace(logger.SIGNATURE_TRACE, "签名: %s", signature)
+	logger.Trace(logger.SIGNATURE_TRACE, "消息: %q", message)
+	logger.Trace(logger.SIGNATURE_TRACE, "消息长度: %d", len(message))
 
 	// 移除0x前缀
 	signature = strings.TrimPrefix(signature, "0x")
 	address = strings.TrimPrefix(address, "0x")
 
-	logger.Info("处理后的地址: %s", address)
-	logger.Info("处理后的签名: %s", signature)
+	logger.Trace(logger.SIGNATURE_TRACE, "处理后的地址: %s", address)
+	logger.Trace(logger.SIGNATURE_TRACE, "处理后的签名: %s", signature)
 
 	// 转换为字节
 	signatureBytes, err := hex.DecodeString(signature)
@@ -49,7 +71,7 @@ func (ws *WalletService) VerifySignature(address, signature, message string) (bo
 		logger.Error("签名解码失败: %v", err)
 		return false, fmt.Errorf("invalid signature format: %v", err)
 	}
-	logger.Info("签名字节长度: %d", len(signatureBytes))
+	logger.Trace(logger.SIGNATURE_TRACE, "签名字节长度: %d", len(signatureBytes))
 
 	// 检查签名长度
 	if len(signatureBytes) != 65 {
@@ -59,7 +81,7 @@ func (ws *WalletService) VerifySignature(address, signature, message string) (bo
 
 	// 检查恢复ID
 	recoveryID := signatureBytes[64]
-	logger.Info("原始恢复ID: %d", recoveryID)
+	logger.Trace(logger.SIGNATURE_TRACE, "原始恢复ID: %d", recoveryID)
 
 	// 以太坊签名中，恢复ID需要减去27
 	if recoveryID != 27 && recoveryID != 28 {
@@ -69,7 +91,7 @@ func (ws *WalletService) VerifySignature(address, signature, message string) (bo
 
 	// 调整恢复ID（27->0, 28->1）
 	adjustedRecoveryID := recoveryID - 27
-	logger.Info("调整后的恢复ID: %d", adjustedRecoveryID)
+	logger.Trace(logger.SIGNATURE_TRACE, "调整后的恢复ID: %d", adjustedRecoveryID)
 
 	// 创建调整后的签名字节
 	adjustedSignatureBytes := make([]byte, 65)
@@ -85,12 +107,12 @@ func (ws *WalletService) VerifySignature(address, signature, message string) (bo
 	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", messageLength)
 	messageToHash := append([]byte(prefix), messageBytes...)
 
-	logger.Info("前缀: %q", prefix)
-	logger.Info("完整消息: %q", messageToHash)
+	logger.Trace(logger.SIGNATURE_TRACE, "前缀: %q", prefix)
+	logger.Trace(logger.SIGNATURE_TRACE, "完整消息: %q", messageToHash)
 
 	// 计算消息哈希
 	messageHash := crypto.Keccak256Hash(messageToHash)
-	logger.Info("消息哈希: %s", messageHash.Hex())
+	logger.Trace(logger.SIGNATURE_TRACE, "消息哈希: %s", messageHash.Hex())
 
 	// 恢复公钥
 	pubKey, err := crypto.SigToPub(messageHash.Bytes(), adjustedSignatureBytes)
@@ -98,17 +120,17 @@ func (ws *WalletService) VerifySignature(address, signature, message string) (bo
 		logger.Error("公钥恢复失败: %v", err)
 		return false, fmt.Errorf("failed to recover public key: %v", err)
 	}
-	logger.Info("恢复的公钥: %s", crypto.CompressPubkey(pubKey))
+	logger.Trace(logger.SIGNATURE_TRACE, "恢复的公钥: %s", crypto.CompressPubkey(pubKey))
 
 	// 从公钥恢复地址
 	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
-	logger.Info("恢复的地址: %s", recoveredAddr.Hex())
-	logger.Info("期望的地址: 0x%s", address)
+	logger.Trace(logger.SIGNATURE_TRACE, "恢复的地址: %s", recoveredAddr.Hex())
+	logger.Trace(logger.SIGNATURE_TRACE, "期望的地址: 0x%s", address)
 
 	// 比较地址
 	result := strings.EqualFold(recoveredAddr.Hex(), "0x"+address)
-	logger.Info("地址匹配结果: %t", result)
-	logger.Info("=== 签名验证调试结束 ===")
+	logger.Trace(logger.SIGNATURE_TRACE, "地址匹配结果: %t", result)
+	logger.Trace(logger.SIGNATURE_TRACE, "=== 签名验证调试结束 ===")
 
 	return result, nil
 }
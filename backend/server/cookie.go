@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"beast-royale-backend/internal/config"
+
+	"github.com/gin-contrib/sessions"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// SessionCookie session cookie的属性，Update后对之后写出的cookie生效
+type SessionCookie struct {
+	options atomic.Pointer[gsessions.Options]
+}
+
+// NewSessionCookie 根据安全配置创建cookie属性
+func NewSessionCookie(cfg *config.SecurityConfig) *SessionCookie {
+	c := &SessionCookie{}
+	c.Update(cfg)
+	return c
+}
+
+// Update 使用新的配置替换cookie属性
+func (c *SessionCookie) Update(cfg *config.SecurityConfig) {
+	c.options.Store(&gsessions.Options{
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		MaxAge:   cfg.SessionTimeout,
+		HttpOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: parseSameSite(cfg.CookieSameSite),
+	})
+}
+
+// Wrap 包装session存储，读取或创建session时使用当前的cookie属性
+// 底层存储在创建session时复制自己的Options，因此在包装中替换，而不是修改底层存储的Options
+func (c *SessionCookie) Wrap(store sessions.Store) sessions.Store {
+	return &cookieStore{Store: store, cookie: c}
+}
+
+func parseSameSite(sameSite string) http.SameSite {
+	switch strings.ToLower(sameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+type cookieStore struct {
+	sessions.Store
+	cookie *SessionCookie
+}
+
+func (s *cookieStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	session, err := s.Store.Get(r, name)
+	s.apply(session)
+	return session, err
+}
+
+func (s *cookieStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session, err := s.Store.New(r, name)
+	s.apply(session)
+	return session, err
+}
+
+func (s *cookieStore) apply(session *gsessions.Session) {
+	if session == nil {
+		return
+	}
+	options := *s.cookie.options.Load()
+	session.Options = &options
+}
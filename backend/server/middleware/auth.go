@@ -1,17 +1,37 @@
 package middleware
 
 import (
+	"beast-royale-backend/internal/admin"
 	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
+	"beast-royale-backend/internal/config"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+	"beast-royale-backend/internal/tracing"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// AuthError 认证失败，RetCode同时作为HTTP状态码：401未登录、403账户已封禁、500无法检查账户状态
+type AuthError struct {
+	RetCode int
+	Message string
+	Reason  string
+}
+
+func unauthorized(reason string) *AuthError {
+	return &AuthError{RetCode: http.StatusUnauthorized, Message: "Authentication required", Reason: reason}
+}
+
 // AuthMiddleware 身份验证中间件 - 支持Action-based AuthType和Redis session
-func AuthMiddleware(cookieName string) gin.HandlerFunc {
+// cookie认证时通过deps检查账户是否被封禁
+func AuthMiddleware(security *config.SecurityConfig, deps *api.Deps) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 对于某些不需要认证的端点，直接放行
 		if isPublicEndpoint(c.Request.URL.Path) {
@@ -25,41 +45,17 @@ func AuthMiddleware(cookieName string) gin.HandlerFunc {
 		params, ok := _params.(*map[string]interface{})
 
 		// 如果是Action-based API，使用AuthType判断
-		if action != "" && ok {
-			authType := api.GetActionAuthType(action)
-
-			switch authType {
-			case api.NOAUTH:
-				// 无需认证，直接放行
-				c.Next()
-				return
-			case api.COOKIEAUTH:
-				// 基于cookie-session的认证
-				if handleCookieAuth(c, params, cookieName) {
-					c.Next()
-					return
-				}
-				// 认证失败，返回401
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"RetCode": 401,
-					"Message": "Authentication required",
-					"Error":   "Session not found or expired",
-				})
-				return
-			case api.VERIFYAUTH:
-				// 基于签名的认证（保持现有逻辑）
-				if handleTokenAuth(c) {
-					c.Next()
-					return
-				}
-				// 认证失败，返回401
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"RetCode": 401,
-					"Message": "Authentication required",
-					"Error":   "Invalid signature or token",
+		if action != "" && ok && api.Exist(action) {
+			if authErr := AuthorizeAction(c, action, params, security, deps); authErr != nil {
+				codec.AbortWithStatus(c, authErr.RetCode, gin.H{
+					"RetCode": authErr.RetCode,
+					"Message": authErr.Message,
+					"Error":   authErr.Reason,
 				})
 				return
 			}
+			c.Next()
+			return
 		}
 
 		// 对于非Action-based API，使用传统的token认证
@@ -69,7 +65,7 @@ func AuthMiddleware(cookieName string) gin.HandlerFunc {
 		}
 
 		// 认证失败
-		logger.Error("认证失败 - Path: %s, Client: %s", c.Request.URL.Path, c.ClientIP())
+		logger.WarnContext(c, "认证失败 - Path: %s, Client: %s", c.Request.URL.Path, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"Success": false,
 			"Message": "Unauthorized",
@@ -79,24 +75,87 @@ func AuthMiddleware(cookieName string) gin.HandlerFunc {
 	}
 }
 
-// handleCookieAuth 处理基于cookie-session的认证
-func handleCookieAuth(c *gin.Context, params *map[string]interface{}, cookieName string) bool {
+// AuthorizeAction 按Action注册的AuthType进行认证，通过时返回nil
+func AuthorizeAction(c *gin.Context, action string, params *map[string]interface{}, security *config.SecurityConfig, deps *api.Deps) *AuthError {
+	authType := api.GetActionAuthType(action).String()
+	_, span := tracing.Start(c.Request.Context(), "auth", trace.WithAttributes(attribute.String("beast.auth_type", authType)))
+	defer span.End()
+
+	authErr := authorizeAction(c, action, params, security, deps)
+	span.SetAttributes(attribute.Bool("beast.auth_passed", authErr == nil))
+	if authErr != nil {
+		metrics.AuthFailed(authType)
+	}
+	return authErr
+}
+
+func authorizeAction(c *gin.Context, action string, params *map[string]interface{}, security *config.SecurityConfig, deps *api.Deps) *AuthError {
+	switch api.GetActionAuthType(action) {
+	case api.NOAUTH:
+		// 无需认证，直接放行
+		return nil
+	case api.COOKIEAUTH:
+		// 基于cookie-session的认证
+		return handleCookieAuth(c, params, deps)
+	case api.VERIFYAUTH:
+		// 基于签名的认证（保持现有逻辑）
+		if handleTokenAuth(c) {
+			return nil
+		}
+		return unauthorized("Invalid signature or token")
+	case api.ADMINAUTH:
+		// 基于管理密钥的认证
+		if handleAdminAuth(c, security.AdminAPIKeys) {
+			return nil
+		}
+		return unauthorized("Invalid admin key")
+	}
+	return unauthorized("Unsupported auth type")
+}
+
+// handleCookieAuth 处理基于cookie-session的认证，已登录的账户被封禁后session立即失效
+func handleCookieAuth(c *gin.Context, params *map[string]interface{}, deps *api.Deps) *AuthError {
 	// 检查cookie是否存在（gin-sessions会自动处理session ID）
 	session := sessions.Default(c)
 
 	// 从session中获取地址
 	addr := session.Get("address")
 	if addr == nil {
-		logger.Error("Session not found or expired")
-		return false
+		logger.WarnContext(c, "Session not found or expired")
+		return unauthorized("Session not found or expired")
 	}
 
 	address := addr.(string)
 
+	// 每次请求都检查封禁状态，封禁后已登录的session不能再调用任何需要登录的Action
+	err := api.CheckAccount(c.Request.Context(), deps.Store.UserProfiles(), address)
+	if errors.Is(err, api.ErrAccountSuspended) {
+		logger.WarnContext(c, "已封禁的用户 %s 使用已登录的session", address)
+		return &AuthError{RetCode: http.StatusForbidden, Message: "Account suspended", Reason: "Session belongs to a suspended account"}
+	}
+	if err != nil {
+		logger.ErrorContext(c, "检查账户状态失败: %v", err)
+		return &AuthError{RetCode: http.StatusInternalServerError, Message: "Failed to check account status", Reason: "Account status lookup failed"}
+	}
+
 	// 将session中的地址写入params，替代请求中的Address
 	(*params)["Address"] = address
-	logger.Info("Cookie auth successful for address: %s", address)
+	// 后续日志自动带上已登录的地址
+	c.Set("address", address)
+	logger.DebugContext(c, "Cookie auth successful")
 
+	return nil
+}
+
+// handleAdminAuth 校验请求头中的管理密钥，通过后记录密钥名称作为审计记录的操作人
+func handleAdminAuth(c *gin.Context, keys map[string]string) bool {
+	name, ok := admin.MatchKey(keys, c.GetHeader(admin.ADMIN_KEY_HEADER))
+	if !ok {
+		logger.WarnContext(c, "管理密钥无效 - Client: %s", c.ClientIP())
+		return false
+	}
+	c.Set(api.ADMIN_KEY_NAME, name)
+	logger.DebugContext(c, "Admin auth successful - Key: %s", name)
 	return true
 }
 
@@ -144,8 +203,6 @@ func isPublicEndpoint(path string) bool {
 	publicPaths := []string{
 		"/",
 		"/health",
-		"/api/v1/wallet/connect",
-		"/api/v1/wallet/verify",
 	}
 
 	for _, publicPath := range publicPaths {
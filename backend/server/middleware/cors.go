@@ -1,22 +1,84 @@
 package middleware
 
 import (
+	"net/http"
+	"sync/atomic"
+
+	"beast-royale-backend/internal/config"
+
 	"github.com/gin-gonic/gin"
-	cors "github.com/rs/cors/wrapper/gin"
+	"github.com/rs/cors"
+	corsgin "github.com/rs/cors/wrapper/gin"
 )
 
-// CORSMiddleware 配置CORS中间件
-func CORSMiddleware() gin.HandlerFunc {
-	return cors.New(cors.Options{
-		AllowedOrigins: []string{
-			"http://localhost:5173",
-			"http://localhost:5174",
-			"http://localhost:3000",
-			"http://localhost:3001",
-			"https://*.ngrok-free.app", // 允许所有ngrok域名
-		},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Request-ID"},
-		AllowCredentials: true, // 允许发送cookies
-	})
+// 未配置时使用的默认方法和请求头
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Request-ID"}
+)
+
+// corsExposedHeaders 前端需要读取的响应头，由服务自身决定，不需要配置
+var corsExposedHeaders = []string{"X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"}
+
+// CORSPolicy 根据配置生成的跨域策略，Update后对之后的请求立即生效
+type CORSPolicy struct {
+	current atomic.Pointer[corsRules]
+}
+
+// corsRules 同一份配置生成的中间件和来源检查，WebSocket握手与跨域请求使用相同的规则
+type corsRules struct {
+	handler gin.HandlerFunc
+	origins *cors.Cors // 未配置允许的来源时为nil
+}
+
+// NewCORSPolicy 根据配置创建跨域策略
+func NewCORSPolicy(cfg *config.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update 使用新的配置替换跨域策略
+func (p *CORSPolicy) Update(cfg *config.CORSConfig) {
+	p.current.Store(newCORSRules(cfg))
+}
+
+// AllowsOrigin 按当前策略检查请求的Origin，非浏览器客户端不带Origin时放行
+func (p *CORSPolicy) AllowsOrigin(r *http.Request) bool {
+	if r.Header.Get("Origin") == "" {
+		return true
+	}
+	origins := p.current.Load().origins
+	return origins != nil && origins.OriginAllowed(r)
+}
+
+func newCORSRules(cfg *config.CORSConfig) *corsRules {
+	// 未配置允许的来源时不允许跨域，rs/cors对空列表的默认行为是允许所有来源
+	if len(cfg.AllowedOrigins) == 0 {
+		return &corsRules{handler: func(c *gin.Context) {}}
+	}
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	// 来源支持通配子域名，例如 https://*.example.com
+	options := cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   methods,
+		AllowedHeaders:   headers,
+		ExposedHeaders:   corsExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+	}
+	return &corsRules{handler: corsgin.New(options), origins: cors.New(options)}
+}
+
+// CORSMiddleware 按当前的跨域策略处理请求
+func CORSMiddleware(policy *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy.current.Load().handler(c)
+	}
 }
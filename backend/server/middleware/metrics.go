@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 统计所有HTTP请求的数量和耗时，按路由模板分组
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAuthMiddleware 限制 /metrics 的访问：客户端IP在allow_ips中或携带正确的bearer token
+// 两者都未配置时只允许本机访问
+func MetricsAuthMiddleware(cfg *config.MetricsConfig) gin.HandlerFunc {
+	var nets []*net.IPNet
+	for _, entry := range cfg.AllowIPs {
+		if ipNet := parseIPNet(entry); ipNet != nil {
+			nets = append(nets, ipNet)
+		} else {
+			logger.Error("无效的metrics.allow_ips配置: %s", entry)
+		}
+	}
+	if len(nets) == 0 && cfg.BearerToken == "" {
+		nets = append(nets, parseIPNet("127.0.0.1"), parseIPNet("::1"))
+	}
+
+	return func(c *gin.Context) {
+		if cfg.BearerToken != "" {
+			token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.BearerToken)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		// 路由只采用server.trusted_proxies传入的X-Forwarded-For，客户端无法伪造来源IP绕过白名单
+		if ip := net.ParseIP(c.ClientIP()); ip != nil {
+			for _, ipNet := range nets {
+				if ipNet.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		logger.Warn("拒绝访问metrics - Client: %s", c.ClientIP())
+		c.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// parseIPNet 解析IP或CIDR，单个IP视为/32或/128
+func parseIPNet(entry string) *net.IPNet {
+	if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+		return ipNet
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
@@ -1,8 +1,14 @@
 package middleware
 
 import (
+	"beast-royale-backend/internal/codec"
 	"beast-royale-backend/internal/config"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/tracing"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,79 +18,135 @@ import (
 // PreJobMiddleware 请求预处理中间件
 func PreJobMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 记录请求开始时间
-		start := time.Now()
+		// span只覆盖预处理本身，不包括后续的鉴权和Task
+		_, span := tracing.Start(c.Request.Context(), "PreJobMiddleware")
+		ok := preJob(c, cfg)
+		span.End()
+		if !ok {
+			return
+		}
+
+		// 继续处理请求
+		c.Next()
+	}
+}
 
-		// 设置请求ID
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = generateRequestID()
+// preJob 设置请求ID和配置信息，解析Action请求，请求无效时返回false并中断请求
+func preJob(c *gin.Context, cfg *config.Config) bool {
+	// 记录请求开始时间
+	start := time.Now()
+
+	// 设置请求ID
+	requestID := c.GetHeader("X-Request-ID")
+	if requestID == "" {
+		requestID = generateRequestID()
+	}
+	c.Set("RequestID", requestID)
+
+	// 设置配置信息到context，供后续Task使用
+	c.Set("cookie_name", cfg.Security.CookieName)
+	c.Set("session_timeout", cfg.Security.SessionTimeout)
+
+	// 解析Action-based API请求
+	if c.Request.URL.Path == "/api" {
+		requestCodec, ok := negotiateCodec(c)
+		if !ok {
+			return false
 		}
-		c.Set("RequestID", requestID)
-
-		// 设置配置信息到context，供后续Task使用
-		c.Set("cookie_name", cfg.Security.CookieName)
-		c.Set("session_timeout", cfg.Security.SessionTimeout)
-
-		// 解析Action-based API请求
-		if c.Request.URL.Path == "/api" {
-			// 解析JSON
-			var requestData map[string]interface{}
-			if err := c.ShouldBindJSON(&requestData); err != nil {
-				logger.Error("解析JSON失败: %v", err)
-				c.JSON(400, gin.H{
-					"Success": false,
-					"Message": "Invalid JSON format",
-					"Error":   err.Error(),
-				})
-				c.Abort()
-				return
-			}
-
-			// 提取action
-			action, ok := requestData["Action"].(string)
-			if !ok || action == "" {
-				logger.Error("缺少Action字段")
-				c.JSON(400, gin.H{
-					"Success": false,
-					"Message": "Action field is required",
-					"Error":   "Missing Action field",
-				})
-				c.Abort()
-				return
-			}
-
-			// 检查/生成 RequestUUID
-			reqUUID, ok := requestData["RequestUUID"].(string)
-			if !ok || reqUUID == "" {
-				reqUUID = uuid.NewString()
-				requestData["RequestUUID"] = reqUUID
-				logger.Info("自动生成RequestUUID: %s", reqUUID)
-			}
-
-			// 设置action、params和RequestUUID到context
-			c.Set("action", action)
-			c.Set("params", &requestData)
-			c.Set("RequestUUID", reqUUID)
-
-			logger.Info("解析Action请求 - Action: %s, RequestUUID: %s, RequestID: %s", action, reqUUID, requestID)
+
+		// 解析请求体
+		requestData, err := decodeBody(c, requestCodec, false)
+		if err != nil {
+			logger.ErrorContext(c, "解析请求体失败: %v", err)
+			codec.AbortWithStatus(c, 400, gin.H{
+				"Success": false,
+				"Message": "Invalid request body",
+				"Error":   err.Error(),
+			})
+			return false
 		}
 
-		// 记录请求信息
-		logger.Info("收到请求 - Method: %s, Path: %s, Client: %s, RequestID: %s",
-			c.Request.Method, c.Request.URL.Path, c.ClientIP(), requestID)
+		if err := SetActionContext(c, &requestData); err != nil {
+			logger.ErrorContext(c, "缺少Action字段")
+			codec.AbortWithStatus(c, 400, gin.H{
+				"Success": false,
+				"Message": "Action field is required",
+				"Error":   "Missing Action field",
+			})
+			return false
+		}
+		logger.DebugContext(c, "解析Action请求")
+	}
 
-		// 设置响应头
-		c.Header("X-Request-ID", requestID)
-		c.Header("X-Response-Time", time.Since(start).String())
+	// 记录请求信息，请求完成的访问日志由路由上的日志中间件输出
+	logger.DebugContext(c, "收到请求 - Method: %s, Path: %s, Client: %s",
+		c.Request.Method, c.Request.URL.Path, c.ClientIP())
 
-		// 继续处理请求
-		c.Next()
+	// 设置响应头
+	c.Header("X-Request-ID", requestID)
+	c.Header("X-Response-Time", time.Since(start).String())
 
-		// 记录响应信息
-		logger.Info("请求完成 - Method: %s, Path: %s, Status: %d, Duration: %v, RequestID: %s",
-			c.Request.Method, c.Request.URL.Path, c.Writer.Status(), time.Since(start), requestID)
+	return true
+}
+
+// SetActionContext 提取Action并检查/生成RequestUUID，写入context供鉴权和Handle使用
+func SetActionContext(c *gin.Context, requestData *map[string]interface{}) error {
+	// 提取action
+	action, ok := (*requestData)["Action"].(string)
+	if !ok || action == "" {
+		return errors.New("missing Action field")
+	}
+
+	// 检查/生成 RequestUUID
+	reqUUID, ok := (*requestData)["RequestUUID"].(string)
+	if !ok || reqUUID == "" {
+		reqUUID = uuid.NewString()
+		(*requestData)["RequestUUID"] = reqUUID
+		logger.DebugContext(c, "自动生成RequestUUID: %s", reqUUID)
+	}
+
+	// 设置action、params和RequestUUID到context
+	c.Set("action", action)
+	c.Set("params", requestData)
+	c.Set("RequestUUID", reqUUID)
+	return nil
+}
+
+// negotiateCodec 按Content-Type选择请求的编解码器，响应格式按Accept协商，默认与请求一致
+// 不支持的Content-Type直接返回415并中断请求
+func negotiateCodec(c *gin.Context) (codec.Codec, bool) {
+	requestCodec, ok := codec.ForContentType(c.ContentType())
+	if !ok {
+		logger.ErrorContext(c, "不支持的Content-Type: %s", c.ContentType())
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"Success": false,
+			"Message": "Unsupported Content-Type",
+			"Error":   "Supported types: application/json, application/msgpack, application/cbor",
+		})
+		return nil, false
+	}
+	c.Set("codec", codec.Negotiate(c.GetHeader("Accept"), requestCodec))
+	return requestCodec, true
+}
+
+// decodeBody 解析请求体，请求体必须是对象，allowEmpty为true时空请求体返回空对象
+func decodeBody(c *gin.Context, requestCodec codec.Codec, allowEmpty bool) (map[string]interface{}, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if allowEmpty && len(bytes.TrimSpace(body)) == 0 {
+		return make(map[string]interface{}), nil
+	}
+
+	var requestData map[string]interface{}
+	if err := requestCodec.Decode(body, &requestData); err != nil {
+		return nil, err
+	}
+	if requestData == nil {
+		return nil, errors.New("request body must be an object")
 	}
+	return requestData, nil
 }
 
 // generateRequestID 生成请求ID
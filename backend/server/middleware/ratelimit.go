@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/ratelimit"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// limitCheck 某个身份维度上需要执行的限流检查
+type limitCheck struct {
+	spec     *config.LimitSpec
+	identity string
+}
+
+// RateLimitMiddleware 按IP、地址和Action限流中间件，需放在PreJobMiddleware之后
+func RateLimitMiddleware(rules *ratelimit.Rules, limiter ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := c.GetString("action")
+		if action == "" {
+			c.Next()
+			return
+		}
+
+		tightest := CheckRateLimit(c, rules, limiter, action)
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, tightest)
+		if tightest.Allowed {
+			c.Next()
+			return
+		}
+
+		resp := NewRateLimitedResponse(c, action, tightest)
+		c.Header("Retry-After", strconv.Itoa(resp.RetryAfter))
+		logger.InfoContext(c, "请求被限流 - Action: %s, Client: %s, RetryAfter: %ds", action, c.ClientIP(), resp.RetryAfter)
+		codec.AbortWithStatus(c, http.StatusTooManyRequests, resp)
+	}
+}
+
+// NewRateLimitedResponse 构造被限流时返回给客户端的响应
+func NewRateLimitedResponse(c *gin.Context, action string, result *ratelimit.Result) *api.RateLimitedResponse {
+	return &api.RateLimitedResponse{
+		BaseResponse: api.BaseResponse{
+			Action:      action + "Response",
+			RequestUUID: c.GetString("RequestUUID"),
+			RetCode:     api.RATE_LIMITED_CODE,
+			Message:     "Too many requests",
+		},
+		RetryAfter: ceilSeconds(result.RetryAfter),
+	}
+}
+
+// CheckRateLimit 对一次Action调用执行限流检查，返回最紧张的一条规则的结果；没有生效的规则时返回nil
+// 某条规则拒绝时，之前已经允许的规则归还这次请求占用的额度，例如按地址被限流的请求不消耗IP的额度
+func CheckRateLimit(c *gin.Context, rules *ratelimit.Rules, limiter ratelimit.Limiter, action string) *ratelimit.Result {
+	rule := rules.Get(action)
+	checks := []limitCheck{{rule.IP, "ip:" + c.ClientIP()}}
+	if address := rateLimitAddress(c); address != "" {
+		checks = append(checks, limitCheck{rule.Address, "addr:" + address})
+	}
+
+	// 多条规则同时生效时，取剩余额度最少的那一条
+	var tightest *ratelimit.Result
+	var counted []countedCheck
+	for _, check := range checks {
+		if check.spec == nil || check.spec.Limit <= 0 || check.spec.Window <= 0 {
+			continue
+		}
+
+		key := action + ":" + check.identity
+		result, err := limiter.Allow(c.Request.Context(), key, check.spec.Limit, time.Duration(check.spec.Window)*time.Second)
+		if err != nil {
+			// 限流后端故障时放行，避免Redis抖动导致整体不可用
+			logger.ErrorContext(c, "限流检查失败 - Key: %s, Error: %v", key, err)
+			continue
+		}
+
+		if tightest == nil || !result.Allowed || result.Remaining < tightest.Remaining {
+			tightest = result
+		}
+		if !result.Allowed {
+			refund(c, limiter, counted)
+			break
+		}
+		counted = append(counted, countedCheck{key, result})
+	}
+
+	return tightest
+}
+
+// countedCheck 已经允许并计入额度的一条规则
+type countedCheck struct {
+	key    string
+	result *ratelimit.Result
+}
+
+// refund 归还被拒绝的请求在其他规则上占用的额度，失败时只记录日志
+func refund(c *gin.Context, limiter ratelimit.Limiter, checks []countedCheck) {
+	for _, check := range checks {
+		if err := limiter.Refund(c.Request.Context(), check.key, check.result); err != nil {
+			logger.ErrorContext(c, "归还限流额度失败 - Key: %s, Error: %v", check.key, err)
+		}
+	}
+}
+
+// rateLimitAddress 获取用于限流的钱包地址，优先使用session中已登录的地址
+func rateLimitAddress(c *gin.Context) string {
+	if addr, ok := sessions.Default(c).Get("address").(string); ok && addr != "" {
+		return addr
+	}
+
+	_params, _ := c.Get("params")
+	if params, ok := _params.(*map[string]interface{}); ok {
+		if addr, ok := (*params)[api.ADDRESS].(string); ok {
+			return strings.ToLower(addr)
+		}
+	}
+	return ""
+}
+
+// setRateLimitHeaders 在响应头中写入当前限流状态
+func setRateLimitHeaders(c *gin.Context, result *ratelimit.Result) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
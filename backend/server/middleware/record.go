@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/mock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordWriter 在写出响应的同时保留一份响应体
+type recordWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// RecordMiddleware 把Action请求和响应录制为mock fixture，放在PreJobMiddleware之后，仅用于开发环境
+func RecordMiddleware(recorder *mock.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := c.GetString("action")
+		_params, _ := c.Get("params")
+		params, ok := _params.(*map[string]interface{})
+		if action == "" || !ok || !recorder.Enabled(action) {
+			c.Next()
+			return
+		}
+
+		// 鉴权会改写params中的Address，需要在执行前复制请求参数
+		request := make(map[string]interface{}, len(*params))
+		for k, v := range *params {
+			if k != "Action" && k != "RequestUUID" {
+				request[k] = v
+			}
+		}
+
+		w := &recordWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		responseCodec := codec.JSON
+		if v, ok := c.Get("codec"); ok {
+			if negotiated, ok := v.(codec.Codec); ok {
+				responseCodec = negotiated
+			}
+		}
+		var response map[string]interface{}
+		if err := responseCodec.Decode(w.body.Bytes(), &response); err != nil {
+			logger.ErrorContext(c, "录制响应解析失败 - Action: %s, Error: %v", action, err)
+			return
+		}
+		if err := recorder.Record(action, logger.RedactMap(request, api.SensitiveParams(action)...), w.Status(), response); err != nil {
+			logger.ErrorContext(c, "保存fixture失败 - Action: %s, Error: %v", action, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/codec"
+	"beast-royale-backend/internal/logger"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteMiddleware 将REST请求转换为Action请求，放在PreJobMiddleware之后
+// 请求体按Content-Type解析，路径、查询参数按路由声明绑定，之后的限流、鉴权和Handle与/api完全一致
+func RouteMiddleware(route api.Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestCodec, ok := negotiateCodec(c)
+		if !ok {
+			return
+		}
+
+		requestData, err := decodeBody(c, requestCodec, true)
+		if err != nil {
+			logger.ErrorContext(c, "解析请求体失败 - Path: %s, Error: %v", c.FullPath(), err)
+			codec.AbortWithStatus(c, 400, gin.H{
+				"Success": false,
+				"Message": "Invalid request body",
+				"Error":   err.Error(),
+			})
+			return
+		}
+
+		if err := bindRouteParams(c, route, requestData); err != nil {
+			logger.ErrorContext(c, "绑定路由参数失败 - Path: %s, Error: %v", c.FullPath(), err)
+			codec.AbortWithStatus(c, 400, gin.H{
+				"Success": false,
+				"Message": "Invalid request params",
+				"Error":   err.Error(),
+			})
+			return
+		}
+
+		// Action由路由决定，不允许请求体覆盖
+		requestData["Action"] = route.Action
+		if err := SetActionContext(c, &requestData); err != nil {
+			codec.AbortWithStatus(c, 400, gin.H{
+				"Success": false,
+				"Message": "Action field is required",
+				"Error":   err.Error(),
+			})
+			return
+		}
+
+		logger.InfoContext(c, "解析REST请求 - Method: %s, Path: %s, Action: %s, RequestUUID: %s",
+			c.Request.Method, c.FullPath(), route.Action, c.GetString("RequestUUID"))
+		c.Next()
+	}
+}
+
+// bindRouteParams 按声明把请求体字段、查询参数和路径参数写入Action参数，优先级依次升高
+func bindRouteParams(c *gin.Context, route api.Route, requestData map[string]interface{}) error {
+	for _, source := range []api.ParamSource{api.BODY_SOURCE, api.QUERY_SOURCE, api.PATH_SOURCE} {
+		for _, p := range route.Params {
+			if p.Source != source {
+				continue
+			}
+
+			var value interface{}
+			var found bool
+			switch p.Source {
+			case api.BODY_SOURCE:
+				value, found = requestData[p.Name]
+				if found && p.Name != p.Param {
+					delete(requestData, p.Name)
+				}
+			case api.QUERY_SOURCE:
+				value, found = c.GetQuery(p.Name)
+			case api.PATH_SOURCE:
+				value = c.Param(p.Name)
+				found = value != ""
+			}
+
+			if !found {
+				if p.Required {
+					return fmt.Errorf("%s is required", p.Name)
+				}
+				continue
+			}
+
+			converted, err := convertParam(value, p.Kind)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", p.Name, err)
+			}
+			requestData[p.Param] = converted
+		}
+	}
+	return nil
+}
+
+// convertParam 将字符串参数转换为声明的类型，非字符串的值（例如JSON中的数字）原样返回
+func convertParam(value interface{}, kind api.ParamKind) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+
+	switch kind {
+	case api.INT_KIND:
+		return strconv.Atoi(s)
+	case api.BOOL_KIND:
+		return strconv.ParseBool(s)
+	}
+	return s, nil
+}
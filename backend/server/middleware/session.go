@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+	"beast-royale-backend/internal/sessionkeys"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionReissueMiddleware 请求带有旧密钥签发的session cookie时，用当前签名密钥重新签发，
+// 密钥轮换后已登录的用户不会被登出，需放在sessions中间件之后
+func SessionReissueMiddleware(name string, store sessions.Store, keyring *sessionkeys.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if keyring.NeedsReissue(c.Request, name) {
+			// 与sessions中间件共用请求上的session缓存，之后的读写拿到的是同一个session
+			session, err := store.Get(c.Request, name)
+			if err == nil && !session.IsNew {
+				if err := session.Save(c.Request, c.Writer); err != nil {
+					logger.WarnContext(c, "重新签发session cookie失败 - Error: %v", err)
+				} else {
+					metrics.SessionReissued()
+				}
+			}
+		}
+		c.Next()
+	}
+}
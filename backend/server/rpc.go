@@ -0,0 +1,218 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/handle"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/ratelimit"
+	"beast-royale-backend/server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSON-RPC 2.0 标准错误码
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcServerError    = -32000 // -32000 ~ -32099 为服务端自定义错误，由RetCode映射
+)
+
+// rpcRequest JSON-RPC 2.0 请求，method对应Action名称，params对应Action参数
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcResponse JSON-RPC 2.0 响应
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError JSON-RPC 2.0 错误对象，data中携带原始的Action响应（含RetCode）
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// isNotification 没有id成员的请求为通知，不返回响应
+func (req *rpcRequest) isNotification() bool {
+	return req.ID == nil
+}
+
+// RPCHandler JSON-RPC 2.0 传输层，复用/api的Action注册表、鉴权和限流逻辑
+func RPCHandler(cfg *config.Config, deps *api.Deps, limiter ratelimit.Limiter, rules *ratelimit.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusOK, newRPCError(nil, rpcParseError, "Parse error", nil))
+			return
+		}
+
+		body = bytes.TrimSpace(body)
+		if len(body) > 0 && body[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(body, &batch); err != nil {
+				c.JSON(http.StatusOK, newRPCError(nil, rpcParseError, "Parse error", nil))
+				return
+			}
+			if len(batch) == 0 {
+				c.JSON(http.StatusOK, newRPCError(nil, rpcInvalidRequest, "Invalid Request", nil))
+				return
+			}
+			// 限流按HTTP请求计数，批量过大时整体拒绝，不执行其中任何调用
+			if max := cfg.Server.RPCMaxBatchSize; len(batch) > max {
+				c.JSON(http.StatusOK, newRPCError(nil, rpcInvalidRequest, "Invalid Request",
+					fmt.Sprintf("batch contains %d calls, at most %d allowed", len(batch), max)))
+				return
+			}
+
+			responses := make([]*rpcResponse, 0, len(batch))
+			for _, raw := range batch {
+				if resp := handleRPCCall(c, cfg, deps, limiter, rules, raw); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+
+			// 全部是通知时不返回任何内容
+			if len(responses) == 0 {
+				c.Status(http.StatusNoContent)
+				return
+			}
+			c.JSON(http.StatusOK, responses)
+			return
+		}
+
+		resp := handleRPCCall(c, cfg, deps, limiter, rules, body)
+		if resp == nil {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// handleRPCCall 处理单个JSON-RPC调用，通知返回nil
+func handleRPCCall(c *gin.Context, cfg *config.Config, deps *api.Deps, limiter ratelimit.Limiter, rules *ratelimit.Rules, raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return newRPCError(nil, rpcParseError, "Parse error", nil)
+		}
+		return newRPCError(nil, rpcInvalidRequest, "Invalid Request", nil)
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return newRPCError(req.ID, rpcInvalidRequest, "Invalid Request", nil)
+	}
+
+	resp := callAction(c, cfg, deps, limiter, rules, &req)
+	if req.isNotification() {
+		return nil
+	}
+	return resp
+}
+
+// callAction 将JSON-RPC调用转换为Action请求，依次经过限流、鉴权和任务执行
+func callAction(c *gin.Context, cfg *config.Config, deps *api.Deps, limiter ratelimit.Limiter, rules *ratelimit.Rules, req *rpcRequest) *rpcResponse {
+	if !api.Exist(req.Method) {
+		return newRPCError(req.ID, rpcMethodNotFound, "Method not found", nil)
+	}
+
+	// 只支持按名称传参，params必须是对象
+	requestData := make(map[string]interface{})
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &requestData); err != nil {
+			return newRPCError(req.ID, rpcInvalidParams, "Invalid params", "params must be an object")
+		}
+	}
+	requestData["Action"] = req.Method
+
+	if err := middleware.SetActionContext(c, &requestData); err != nil {
+		return newRPCError(req.ID, rpcInvalidParams, "Invalid params", err.Error())
+	}
+	reqUUID := c.GetString("RequestUUID")
+
+	if limiter != nil {
+		if result := middleware.CheckRateLimit(c, rules, limiter, req.Method); result != nil && !result.Allowed {
+			resp := middleware.NewRateLimitedResponse(c, req.Method, result)
+			return newRPCError(req.ID, rpcErrorCode(resp.RetCode), resp.Message, resp)
+		}
+	}
+
+	if authErr := middleware.AuthorizeAction(c, req.Method, &requestData, &cfg.Security, deps); authErr != nil {
+		resp := api.MakeErrorResponse(authErr.RetCode, authErr.Message+": "+authErr.Reason)
+		resp.SetAction(req.Method + "Response")
+		resp.SetSession(reqUUID)
+		return newRPCError(req.ID, rpcErrorCode(resp.RetCode), resp.Message, resp)
+	}
+
+	response, err := handle.Dispatch(c, deps, req.Method, &requestData)
+	switch {
+	case errors.Is(err, handle.ErrInvalidParams):
+		return newRPCError(req.ID, rpcInvalidParams, "Invalid params", response)
+	case errors.Is(err, handle.ErrUnknownAction):
+		return newRPCError(req.ID, rpcMethodNotFound, "Method not found", nil)
+	case err != nil:
+		logger.ErrorContext(c, "JSON-RPC调用失败 - Method: %s, Error: %v", req.Method, err)
+		return newRPCError(req.ID, rpcInternalError, "Internal error", response)
+	}
+
+	// RetCode为0或206（部分成功）时作为result返回，其余作为error返回
+	if retCode := response.GetRetCode(); retCode != 0 && retCode != 206 {
+		return newRPCError(req.ID, rpcErrorCode(retCode), response.GetMessage(), response)
+	}
+	return &rpcResponse{
+		JSONRPC: "2.0",
+		Result:  response,
+		ID:      req.ID,
+	}
+}
+
+// rpcErrorCode 将RetCode映射为JSON-RPC服务端错误码（-32000 ~ -32099）
+func rpcErrorCode(retCode int) int {
+	switch retCode {
+	case 401:
+		return rpcServerError - 1
+	case 403:
+		return rpcServerError - 3
+	case 404:
+		return rpcServerError - 4
+	case api.RATE_LIMITED_CODE:
+		return rpcServerError - 29
+	case 500:
+		return rpcInternalError
+	}
+	return rpcServerError
+}
+
+func newRPCError(id json.RawMessage, code int, message string, data interface{}) *rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{
+		JSONRPC: "2.0",
+		Error: &rpcError{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+		ID: id,
+	}
+}
@@ -2,63 +2,162 @@ package server
 
 import (
 	"context"
-	"io"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
-	"path"
+	"os"
 	"strings"
-	"sync"
 	"time"
 
+	"beast-royale-backend/internal/api"
 	"beast-royale-backend/internal/config"
 	"beast-royale-backend/internal/handle"
+	"beast-royale-backend/internal/health"
+	"beast-royale-backend/internal/lifecycle"
 	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/metrics"
+	"beast-royale-backend/internal/mock"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/internal/ratelimit"
+	"beast-royale-backend/internal/sessionkeys"
+	"beast-royale-backend/internal/sessionstore"
+	"beast-royale-backend/internal/tracing"
+	"beast-royale-backend/internal/version"
 	"beast-royale-backend/server/middleware"
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/redis"
 	"github.com/gin-gonic/gin"
-	rotatelogs "github.com/lestrrat/go-file-rotatelogs"
 )
 
 type BeastRoyaleServer struct {
-	e      *gin.Engine
-	server *http.Server
-	wg     *sync.WaitGroup
-	config *config.Config
+	e        *gin.Engine
+	server   *http.Server
+	inflight *lifecycle.Tracker
+	policies *policies
+	config   *config.Config
+	errCh    chan error
 }
 
-func NewServer(cfg *config.Config) *BeastRoyaleServer {
-	wg := &sync.WaitGroup{}
-	r := NewRouter(wg, cfg)
-	return &BeastRoyaleServer{
-		e:      r,
-		wg:     wg,
-		config: cfg,
+// policies 可以在运行中更新的请求处理策略
+type policies struct {
+	cors      *middleware.CORSPolicy
+	cookie    *SessionCookie
+	rateLimit *ratelimit.Rules
+}
+
+func newPolicies(cfg *config.Config) *policies {
+	return &policies{
+		cors:      middleware.NewCORSPolicy(&cfg.CORS),
+		cookie:    NewSessionCookie(&cfg.Security),
+		rateLimit: ratelimit.NewRules(&cfg.RateLimit),
 	}
 }
 
-func (s *BeastRoyaleServer) Run() error {
+// NewServer 创建服务，deps注入每个任务，未设置时返回错误
+func NewServer(cfg *config.Config, deps *api.Deps) (*BeastRoyaleServer, error) {
+	inflight := &lifecycle.Tracker{}
+	p := newPolicies(cfg)
+	r, err := newRouter(inflight, cfg, deps, p)
+	if err != nil {
+		return nil, err
+	}
+	return &BeastRoyaleServer{
+		e:        r,
+		inflight: inflight,
+		policies: p,
+		config:   cfg,
+		errCh:    make(chan error, 1),
+	}, nil
+}
+
+// Reload 使用新的配置更新跨域策略、session cookie属性和限流规则，不需要重启服务
+func (s *BeastRoyaleServer) Reload(cfg *config.Config) {
+	s.policies.cors.Update(&cfg.CORS)
+	s.policies.cookie.Update(&cfg.Security)
+	s.policies.rateLimit.Update(&cfg.RateLimit)
+	logger.Info("请求处理策略已更新 - AllowedOrigins: %v, CookieSecure: %t, CookieSameSite: %q, CookieDomain: %q, RateLimitActions: %d",
+		cfg.CORS.AllowedOrigins, cfg.Security.CookieSecure, cfg.Security.CookieSameSite, cfg.Security.CookieDomain, len(cfg.RateLimit.Actions))
+}
+
+// Start 监听端口并在后台处理请求，端口监听失败时直接返回错误
+// 服务运行中出现的错误通过 Err 返回
+func (s *BeastRoyaleServer) Start() error {
 	addr := s.config.GetServerAddr()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
 	s.server = &http.Server{
 		Addr:    addr,
 		Handler: s.e,
 	}
 
 	logger.Info("服务器启动在地址: %s", addr)
-	return s.server.ListenAndServe()
+	go func() {
+		err := s.server.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errCh <- err
+		}
+		close(s.errCh)
+	}()
+	return nil
+}
+
+// Err 服务异常退出时返回错误，正常关闭时channel被关闭
+func (s *BeastRoyaleServer) Err() <-chan error {
+	return s.errCh
 }
 
-func (s *BeastRoyaleServer) Stop() error {
+// Stop 优雅关闭服务，ctx超时后强制断开剩余连接
+//  1. 标记为未就绪，等待drain_delay让负载均衡摘除流量，期间仍正常处理请求
+//  2. 关闭WebSocket长连接，停止接收新连接
+//  3. 等待进行中的请求处理完成
+func (s *BeastRoyaleServer) Stop(ctx context.Context) error {
 	logger.Info("正在关闭服务器...")
-	err := s.server.Shutdown(context.Background())
-	// whether the err is nil, we should wait for wait group
-	s.wg.Wait()
+	// 先标记为未就绪，负载均衡不再转发新请求
+	health.DefaultChecker.SetShuttingDown()
+	if delay := time.Duration(s.config.Server.DrainDelay) * time.Second; delay > 0 {
+		logger.Info("等待负载均衡摘除流量 - DrainDelay: %v", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	// 长连接已被劫持，http.Server.Shutdown不会等待它们，需要主动关闭
+	push.DefaultHub.CloseAll()
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		// 超时后强制关闭剩余的连接
+		logger.Warn("关闭服务器超时，强制断开连接 - InFlight: %d, Error: %v", s.inflight.Count(), err)
+		s.server.Close()
+	}
+	// Shutdown不会等待已劫持的连接和它们发起的请求，单独等待
+	if waitErr := s.inflight.Wait(ctx); waitErr != nil {
+		logger.Warn("等待进行中的请求超时 - InFlight: %d", s.inflight.Count())
+		if err == nil {
+			err = waitErr
+		}
+	}
 	logger.Info("服务器已关闭")
 	return err
 }
 
-func NewRouter(wg *sync.WaitGroup, cfg *config.Config) *gin.Engine {
+// NewRouter 创建路由，跨域策略、cookie属性和限流规则固定使用cfg中的配置，deps注入每个任务
+// deps未设置，或session密钥、session存储、限流器无法初始化时返回错误
+func NewRouter(inflight *lifecycle.Tracker, cfg *config.Config, deps *api.Deps) (*gin.Engine, error) {
+	return newRouter(inflight, cfg, deps, newPolicies(cfg))
+}
+
+func newRouter(inflight *lifecycle.Tracker, cfg *config.Config, deps *api.Deps, p *policies) (*gin.Engine, error) {
+	// 启动时检查，而不是在第一个请求访问数据库时panic
+	if err := deps.Validate(); err != nil {
+		return nil, err
+	}
+
 	// 设置Gin模式
 	mode := strings.ToLower(getEnv("GIN_MODE", "debug"))
 	switch mode {
@@ -73,145 +172,172 @@ func NewRouter(wg *sync.WaitGroup, cfg *config.Config) *gin.Engine {
 	}
 
 	r := gin.New()
+	// 只采用受信任的反向代理传入的X-Forwarded-For，否则客户端可以伪造IP绕过按IP的限流
+	// 未配置时传nil，不信任任何代理，c.ClientIP()返回连接的对端地址
+	var trustedProxies []string
+	if len(cfg.Server.TrustedProxies) > 0 {
+		trustedProxies = cfg.Server.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
+	// gin.Context作为context.Context使用时回退到c.Request的context，Task中的数据库调用可以拿到trace
+	r.ContextWithFallback = true
+	r.Use(middleware.TracingMiddleware())
 	r.Use(ginLogger())
+	r.Use(middleware.MetricsMiddleware())
 	r.Use(gin.Recovery())
-	r.Use(ginWaitGroup(wg))
+	r.Use(ginInflight(inflight))
 	r.Use(gzip.Gzip(gzip.DefaultCompression))
-	r.Use(middleware.CORSMiddleware())
-
-	// 使用配置文件初始化Redis session store
-	redisAddr := cfg.GetRedisAddr()
-	redisPassword := cfg.Redis.Password
-	redisSecret := cfg.Security.SessionSecret
-
-	store, err := redis.NewStore(
-		cfg.Redis.PoolSize,
-		"tcp",
-		redisAddr,
-		"",
-		redisPassword,
-		[]byte(redisSecret),
-	)
+	r.Use(middleware.CORSMiddleware(p.cors))
+
+	// session cookie使用密钥环签名，第一个密钥签名，所有密钥都可以验证
+	keyring, err := sessionkeys.FromConfig(&cfg.Security)
 	if err != nil {
-		logger.Error("Failed to initialize Redis session store: %v", err)
-		panic("Redis session store is required for authentication")
+		return nil, fmt.Errorf("load session keys: %w", err)
 	}
+	logger.Info("session密钥已加载 - Primary: %s, Keys: %d", keyring.Primary(), len(keyring.Keys))
 
-	// 设置session TTL为配置文件中的值
-	store.Options(sessions.Options{
-		MaxAge:   cfg.Security.SessionTimeout,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,                // 开发环境设为false，生产环境设为true
-		SameSite: http.SameSiteLaxMode, // 添加SameSite设置
-	})
+	// session存储由security.session_store选择：redis、memory或cookie
+	backend, err := sessionstore.New(cfg, keyring)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("session存储已初始化 - Backend: %s", cfg.Security.SessionStore)
+
+	// session存储的读写错误计入metrics，并为每次读写创建span
+	// cookie的过期时间、Secure、SameSite和Domain由配置决定，可以在运行中更新
+	store := p.cookie.Wrap(tracing.InstrumentSessionStore(metrics.InstrumentSessionStore(backend)))
 
 	// 注册session中间件，使用配置文件中的session名称
 	r.Use(sessions.Sessions(cfg.Security.SessionName, store))
+	// 旧密钥签发的cookie用当前密钥重新签发
+	r.Use(middleware.SessionReissueMiddleware(cfg.Security.SessionName, store, keyring))
 
 	// 注册API路由
-	r.POST("/api", middleware.PreJobMiddleware(cfg), middleware.AuthMiddleware(cfg.Security.CookieName), handle.Handle)
+	var limiter ratelimit.Limiter
+	var recorder gin.HandlerFunc
+	if cfg.Recording.Enabled {
+		// 开发环境录制请求和响应，供mock命令回放
+		logger.Info("请求录制已开启 - Dir: %s, Scenario: %q", cfg.Recording.Dir, cfg.Recording.Scenario)
+		recorder = middleware.RecordMiddleware(mock.NewRecorder(cfg.Recording.Dir, cfg.Recording.Scenario, cfg.Recording.Actions, cfg.Recording.Match))
+	}
+	apiHandlers := []gin.HandlerFunc{middleware.PreJobMiddleware(cfg)}
+	if recorder != nil {
+		apiHandlers = append(apiHandlers, recorder)
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, err = ratelimit.New(&cfg.RateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("init rate limiter: %w", err)
+		}
+		apiHandlers = append(apiHandlers, middleware.RateLimitMiddleware(p.rateLimit, limiter))
+	}
+	apiHandlers = append(apiHandlers, middleware.AuthMiddleware(&cfg.Security, deps), handle.Handle(deps))
+	r.POST("/api", apiHandlers...)
+
+	// 在Action旁声明的REST路由，与/api共用同一套中间件
+	for _, route := range api.GetAllRoutes() {
+		routeHandlers := []gin.HandlerFunc{middleware.PreJobMiddleware(cfg), middleware.RouteMiddleware(route)}
+		if recorder != nil {
+			routeHandlers = append(routeHandlers, recorder)
+		}
+		if limiter != nil {
+			routeHandlers = append(routeHandlers, middleware.RateLimitMiddleware(p.rateLimit, limiter))
+		}
+		routeHandlers = append(routeHandlers, middleware.AuthMiddleware(&cfg.Security, deps), handle.HandleRoute(route, deps))
+		r.Handle(route.Method, route.Path, routeHandlers...)
+	}
+
+	// JSON-RPC 2.0 端点，method对应Action名称
+	r.POST("/rpc", middleware.PreJobMiddleware(cfg), RPCHandler(cfg, deps, limiter, p.rateLimit))
+
+	// WebSocket端点，复用session cookie鉴权，支持服务端推送
+	r.GET("/ws", WebSocketHandler(r, cfg, deps, store, push.DefaultHub, p.cors))
+
+	// Prometheus指标，只允许配置的IP或bearer token访问
+	if cfg.Metrics.Enabled {
+		r.GET(cfg.Metrics.Path, middleware.MetricsAuthMiddleware(&cfg.Metrics), gin.WrapH(metrics.Handler()))
+	}
 
 	// 根路径 - API信息页面
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"name":        "Beast Royale Backend API",
-			"version":     "1.0.0",
+			"version":     version.Version,
+			"commit":      version.Commit,
 			"description": "Beast Royale游戏后端服务",
 			"status":      "running",
 			"endpoints": gin.H{
 				"unified": "/api",
+				"rest":    "/api/v1",
+				"rpc":     "/rpc",
+				"ws":      "/ws",
 				"health":  "/health",
+				"livez":   "/livez",
+				"readyz":  "/readyz",
 			},
 			"documentation": "请查看README.md了解详细API使用方法",
 		})
 	})
 
-	// 健康检查
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "Beast Royale Backend is running",
-		})
-	})
-
-	// 保持向后兼容的端点（过渡期）
-	r.POST("/api/v1/wallet/connect", func(c *gin.Context) {
-		// 转换为统一格式
-		var oldReq struct {
-			Address string `json:"address"`
-		}
-		if err := c.ShouldBindJSON(&oldReq); err != nil {
-			c.JSON(400, gin.H{"success": false, "message": "Invalid request"})
-			return
-		}
-
-		// 构造统一格式的请求
-		unifiedReq := gin.H{
-			"Action":  "ConnectWallet",
-			"Address": oldReq.Address,
-		}
-		c.Set("unified_request", unifiedReq)
-		handle.Handle(c)
+	// 存活检查只反映进程本身，就绪检查探测数据库和Redis，关闭过程中返回503
+	r.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, health.DefaultChecker.Live())
 	})
+	r.GET("/readyz", readyHandler)
+	// 兼容旧的健康检查地址，与 /readyz 相同
+	r.GET("/health", readyHandler)
 
-	r.POST("/api/v1/wallet/verify", func(c *gin.Context) {
-		// 转换为统一格式
-		var oldReq struct {
-			Address   string `json:"address"`
-			Signature string `json:"signature"`
-			Message   string `json:"message"`
-		}
-		if err := c.ShouldBindJSON(&oldReq); err != nil {
-			c.JSON(400, gin.H{"success": false, "message": "Invalid request"})
-			return
-		}
-
-		// 构造统一格式的请求
-		unifiedReq := gin.H{
-			"Action":    "VerifySignature",
-			"Address":   oldReq.Address,
-			"Signature": oldReq.Signature,
-			"Message":   oldReq.Message,
-		}
-		c.Set("unified_request", unifiedReq)
-		handle.Handle(c)
-	})
+	return r, nil
+}
 
-	return r
+func readyHandler(c *gin.Context) {
+	report := health.DefaultChecker.Ready(c)
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
 }
 
-func ginWaitGroup(wg *sync.WaitGroup) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		ctx.Set("SERVER_WAIT_GROUP", wg)
+// ginInflight 记录进行中的请求，关闭服务时等待它们处理完成
+// WebSocket连接在整个连接期间计为一个请求
+func ginInflight(inflight *lifecycle.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inflight.Add()
+		metrics.RequestStarted()
+		defer func() {
+			metrics.RequestFinished()
+			inflight.Done()
+		}()
+		c.Next()
 	}
 }
 
+// ginLogger 访问日志，与业务日志使用同一个logger，并带上RequestID、Action等请求字段
 func ginLogger() gin.HandlerFunc {
-	// 使用简单的控制台日志，如果需要文件日志可以扩展
-	return gin.LoggerWithWriter(gin.DefaultWriter)
-}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
 
-// 如果需要文件日志，可以使用这个函数
-func newRotateLogger(dir, prefix, suffix string, maxAge, rotationTime int) (io.Writer, error) {
-	baseLogName := prefix + "." + suffix
-	baseLogPath := path.Join(dir, baseLogName)
-	writer, err := rotatelogs.New(
-		baseLogPath+".%Y%m%d%H%M",
-		rotatelogs.WithLinkName(baseLogPath),                               // 生成软链，指向最新日志文件
-		rotatelogs.WithMaxAge(time.Duration(maxAge)*24*time.Hour),          // 文件最大保存时间
-		rotatelogs.WithRotationTime(time.Duration(rotationTime)*time.Hour), // 日志切割时间间隔
-	)
-	if err != nil {
-		return nil, err
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Logger().LogAttrs(c, level, "请求完成",
+			slog.String("Method", c.Request.Method),
+			slog.String("Path", c.Request.URL.Path),
+			slog.Int("Status", c.Writer.Status()),
+			slog.Duration("Duration", time.Since(start)),
+			slog.String("Client", c.ClientIP()),
+		)
 	}
-
-	return writer, nil
 }
 
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
-	if value := strings.TrimSpace(key); value != "" {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
 		return value
 	}
 	return defaultValue
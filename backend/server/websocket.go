@@ -0,0 +1,424 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"beast-royale-backend/internal/api"
+	"beast-royale-backend/internal/config"
+	"beast-royale-backend/internal/logger"
+	"beast-royale-backend/internal/push"
+	"beast-royale-backend/server/middleware"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket层自行处理的控制帧，不经过Action注册表
+const (
+	wsSubscribeAction   = "Subscribe"
+	wsUnsubscribeAction = "Unsubscribe"
+)
+
+// WebSocketHandler 已登录用户的WebSocket端点
+// 客户端发送与/api相同的 {Action, RequestUUID, ...} 帧，服务端经由/api完整的中间件链处理后回写响应，
+// 同时可以通过push.Hub向该连接推送事件；账户被封禁后连接在下一条帧或下一次心跳时断开
+// 握手的Origin按当前的跨域策略检查，与CORS的通配规则和配置重载保持一致
+func WebSocketHandler(engine *gin.Engine, cfg *config.Config, deps *api.Deps, store sessions.Store, hub *push.Hub, origins *middleware.CORSPolicy) gin.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     origins.AllowsOrigin,
+	}
+
+	return func(c *gin.Context) {
+		// 复用登录session，未登录不允许建立连接
+		address, _ := sessions.Default(c).Get("address").(string)
+		if address == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"RetCode": 401,
+				"Message": "Authentication required",
+				"Error":   "Session not found or expired",
+			})
+			return
+		}
+		// 被封禁的账户不能建立连接，无法检查时也不放行
+		err := api.CheckAccount(c.Request.Context(), deps.Store.UserProfiles(), address)
+		if errors.Is(err, api.ErrAccountSuspended) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"RetCode": 403,
+				"Message": "Account suspended",
+			})
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(c, "检查账户状态失败: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"RetCode": 500,
+				"Message": "Failed to check account status",
+			})
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade失败时已向客户端返回错误
+			logger.Error("WebSocket升级失败 - Address: %s, Error: %v", address, err)
+			return
+		}
+
+		client := newWSClient(conn, address, c, engine, cfg, deps, store)
+		if !hub.Register(client) {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(time.Second))
+			conn.Close()
+			return
+		}
+		logger.Info("WebSocket连接建立 - Address: %s, Client: %s", address, c.ClientIP())
+
+		go client.writePump()
+		client.readPump(hub)
+
+		hub.Unregister(client)
+		client.Close()
+		<-client.writerDone
+		logger.Info("WebSocket连接关闭 - Address: %s, Client: %s", address, c.ClientIP())
+	}
+}
+
+// wsClient 一个WebSocket连接，读写分别由readPump和writePump负责
+type wsClient struct {
+	conn    *websocket.Conn
+	address string
+	engine  *gin.Engine
+	cfg     *config.Config
+	deps    *api.Deps
+	store   sessions.Store
+
+	// 转发Action请求时携带的请求信息，session cookie随响应更新
+	remoteAddr string
+	header     http.Header
+	cookies    []*http.Cookie
+
+	send       chan []byte
+	done       chan struct{}
+	writerDone chan struct{}
+	closeOnce  sync.Once
+	closeCode  int
+	closeText  string
+}
+
+func newWSClient(conn *websocket.Conn, address string, c *gin.Context, engine *gin.Engine, cfg *config.Config, deps *api.Deps, store sessions.Store) *wsClient {
+	header := http.Header{}
+	for _, key := range []string{"User-Agent", "X-Forwarded-For", "X-Real-IP"} {
+		if v := c.GetHeader(key); v != "" {
+			header.Set(key, v)
+		}
+	}
+
+	return &wsClient{
+		conn:       conn,
+		address:    address,
+		engine:     engine,
+		cfg:        cfg,
+		deps:       deps,
+		store:      store,
+		remoteAddr: c.Request.RemoteAddr,
+		header:     header,
+		cookies:    c.Request.Cookies(),
+		send:       make(chan []byte, cfg.WebSocket.SendQueueSize),
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+}
+
+// Address 实现push.Client
+func (ws *wsClient) Address() string {
+	return ws.address
+}
+
+// Deliver 实现push.Client，发送队列已满时返回false；已关闭的连接静默丢弃
+func (ws *wsClient) Deliver(payload []byte) bool {
+	select {
+	case <-ws.done:
+		return true
+	default:
+	}
+
+	select {
+	case ws.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 实现push.Client
+func (ws *wsClient) Close() {
+	ws.closeWith(websocket.CloseGoingAway, "server shutting down")
+}
+
+func (ws *wsClient) closeWith(code int, text string) {
+	ws.closeOnce.Do(func() {
+		ws.closeCode = code
+		ws.closeText = text
+		close(ws.done)
+	})
+}
+
+// readPump 读取客户端帧并逐条处理，连接断开或超时后返回
+func (ws *wsClient) readPump(hub *push.Hub) {
+	wsCfg := ws.cfg.WebSocket
+	pongTimeout := time.Duration(wsCfg.PongTimeout) * time.Second
+
+	ws.conn.SetReadLimit(wsCfg.MaxMessageSize)
+	ws.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		_, message, err := ws.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Error("WebSocket读取失败 - Address: %s, Error: %v", ws.address, err)
+			}
+			return
+		}
+		// 收到任何消息都视为连接存活
+		ws.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		if !ws.handleFrame(hub, message) {
+			return
+		}
+	}
+}
+
+// writePump 串行写出队列中的消息并定时发送心跳，gorilla/websocket要求同一时间只有一个写者
+func (ws *wsClient) writePump() {
+	wsCfg := ws.cfg.WebSocket
+	writeTimeout := time.Duration(wsCfg.WriteTimeout) * time.Second
+	ticker := time.NewTicker(time.Duration(wsCfg.PingInterval) * time.Second)
+	defer func() {
+		ticker.Stop()
+		ws.conn.Close()
+		close(ws.writerDone)
+	}()
+
+	for {
+		select {
+		case payload := <-ws.send:
+			ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := ws.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				logger.Error("WebSocket写入失败 - Address: %s, Error: %v", ws.address, err)
+				ws.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			// 不发送帧的连接也会在心跳时发现账户已被封禁，不再接收推送
+			if ws.suspended() {
+				ws.closeWith(websocket.ClosePolicyViolation, "account suspended")
+				continue
+			}
+			ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := ws.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				ws.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ws.done:
+			// 正常关闭时先写出队列中剩余的响应（例如Logout的响应），再发送关闭帧
+			if ws.closeCode == websocket.CloseNormalClosure {
+				ws.flush(writeTimeout)
+			}
+			if ws.closeCode != websocket.CloseAbnormalClosure {
+				ws.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(ws.closeCode, ws.closeText),
+					time.Now().Add(writeTimeout))
+			}
+			return
+		}
+	}
+}
+
+// flush 写出发送队列中剩余的消息
+func (ws *wsClient) flush(writeTimeout time.Duration) {
+	for {
+		select {
+		case payload := <-ws.send:
+			ws.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := ws.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// handleFrame 处理一条客户端帧，返回false表示需要断开连接
+func (ws *wsClient) handleFrame(hub *push.Hub, message []byte) bool {
+	// 订阅等控制帧不经过AuthMiddleware，统一在这里检查封禁状态
+	if ws.suspended() {
+		ws.closeWith(websocket.ClosePolicyViolation, "account suspended")
+		return false
+	}
+
+	var frame map[string]interface{}
+	if err := json.Unmarshal(message, &frame); err == nil {
+		action, _ := frame["Action"].(string)
+		switch action {
+		case wsSubscribeAction, wsUnsubscribeAction:
+			return ws.handleSubscription(hub, action, frame)
+		}
+	}
+
+	// 其余帧按/api请求完整走一遍中间件链（预处理、限流、鉴权、Handle）
+	req, err := http.NewRequest(http.MethodPost, "/api", bytes.NewReader(message))
+	if err != nil {
+		logger.Error("构造WebSocket转发请求失败: %v", err)
+		return false
+	}
+	req.RemoteAddr = ws.remoteAddr
+	req.Header = ws.header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range ws.cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := newFrameRecorder()
+	ws.engine.ServeHTTP(rec, req)
+
+	if !ws.Deliver(rec.body.Bytes()) {
+		ws.closeWith(websocket.ClosePolicyViolation, "send queue full")
+		return false
+	}
+
+	// 同步session cookie的变化
+	sessionName := ws.cfg.Security.SessionName
+	for _, cookie := range (&http.Response{Header: rec.header}).Cookies() {
+		if cookie.Name == sessionName {
+			ws.setCookie(cookie)
+		}
+	}
+
+	// session失效（Logout、过期或切换账号）后断开连接
+	if ws.sessionAddress() != ws.address {
+		ws.closeWith(websocket.CloseNormalClosure, "session ended")
+		return false
+	}
+	return true
+}
+
+// suspended 账户是否已被封禁
+// 连接建立时已经检查过，之后查询失败只记录日志，不因为数据库短暂不可用断开所有连接
+func (ws *wsClient) suspended() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(ws.cfg.WebSocket.WriteTimeout)*time.Second)
+	defer cancel()
+	err := api.CheckAccount(ctx, ws.deps.Store.UserProfiles(), ws.address)
+	if err != nil && !errors.Is(err, api.ErrAccountSuspended) {
+		logger.Warn("WebSocket检查账户状态失败 - Address: %s, Error: %v", ws.address, err)
+		return false
+	}
+	return err != nil
+}
+
+// sessionAddress 从session store读取当前cookie对应的登录地址
+func (ws *wsClient) sessionAddress() string {
+	req, err := http.NewRequest(http.MethodGet, "/ws", nil)
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range ws.cookies {
+		req.AddCookie(cookie)
+	}
+
+	session, err := ws.store.Get(req, ws.cfg.Security.SessionName)
+	if err != nil {
+		return ""
+	}
+	address, _ := session.Values["address"].(string)
+	return address
+}
+
+// handleSubscription 处理主题订阅控制帧
+func (ws *wsClient) handleSubscription(hub *push.Hub, action string, frame map[string]interface{}) bool {
+	topic, _ := frame["Topic"].(string)
+	reqUUID, _ := frame["RequestUUID"].(string)
+
+	resp := gin.H{
+		"Action":      action + "Response",
+		"RequestUUID": reqUUID,
+		"RetCode":     0,
+		"Topic":       topic,
+	}
+	if topic == "" {
+		resp["RetCode"] = 400
+		resp["Message"] = "Topic is required"
+	} else if action == wsSubscribeAction {
+		err := hub.Authorize(ws.address, topic)
+		if err == nil {
+			err = hub.Subscribe(ws, topic, ws.cfg.WebSocket.MaxSubscriptions)
+		}
+		switch {
+		case errors.Is(err, push.ErrInvalidTopic):
+			resp["RetCode"] = 400
+			resp["Message"] = "Invalid topic, expected room:<id> or guild:<id>"
+		case errors.Is(err, push.ErrTopicForbidden):
+			logger.Warn("拒绝订阅主题 - Address: %s, Topic: %s", ws.address, topic)
+			resp["RetCode"] = 403
+			resp["Message"] = "Not allowed to subscribe to topic"
+		case errors.Is(err, push.ErrTooManySubscriptions):
+			resp["RetCode"] = 400
+			resp["Message"] = fmt.Sprintf("Too many subscriptions, at most %d per connection", ws.cfg.WebSocket.MaxSubscriptions)
+		}
+	} else {
+		hub.Unsubscribe(ws, topic)
+	}
+
+	payload, _ := json.Marshal(resp)
+	if !ws.Deliver(payload) {
+		ws.closeWith(websocket.ClosePolicyViolation, "send queue full")
+		return false
+	}
+	return true
+}
+
+func (ws *wsClient) setCookie(cookie *http.Cookie) {
+	for i, existing := range ws.cookies {
+		if existing.Name == cookie.Name {
+			ws.cookies[i] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+			return
+		}
+	}
+	ws.cookies = append(ws.cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+}
+
+// frameRecorder 接收转发请求的响应，状态码已体现在响应体的RetCode中
+type frameRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newFrameRecorder() *frameRecorder {
+	return &frameRecorder{
+		header: http.Header{},
+	}
+}
+
+func (r *frameRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *frameRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *frameRecorder) WriteHeader(status int) {}
@@ -97,5 +97,6 @@ fi
 
 echo "✅ MySQL设置完成！"
 echo "连接信息: $MYSQL_IP:$MYSQL_USER"
-echo "现在可以运行以下命令启动后端服务："
+echo "现在可以运行以下命令创建数据表并启动后端服务："
+echo "  ./beast-royale-backend db-migrate up -c config.yaml"
 echo "  ./beast-royale-backend run -c config.yaml" 
\ No newline at end of file
@@ -11,6 +11,8 @@ fi
 
 # 启动后端服务
 cd "$(dirname "$0")"
+echo "执行数据库迁移..."
+go run main.go db-migrate up --config config.yaml || exit 1
 echo "使用go run启动后端..."
 go run main.go run --config config.yaml &
 